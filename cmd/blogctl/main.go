@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/backup_service"
+	"github.com/EDDYCJY/go-gin-example/service/gc_service"
+	"github.com/EDDYCJY/go-gin-example/service/import_service"
+	"github.com/EDDYCJY/go-gin-example/service/media_service"
+	"github.com/EDDYCJY/go-gin-example/service/static_service"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"static":          {"write the published blog as a static site", runStatic},
+	"import-markdown": {"import Markdown posts from a directory or zip archive", runImportMarkdown},
+	"import-wxr":      {"import a WordPress WXR export", runImportWXR},
+	"backup":          {"write the whole site into a backup archive", runBackup},
+	"restore":         {"restore the site from a backup archive", runRestore},
+	"media-refs":      {"record again which media every article uses", runMediaRefs},
+	"gc":              {"quarantine unused uploads and purge expired quarantined files", runGc},
+}
+
+// blogctl runs maintenance tasks against the same configuration as the server,
+// so it must be started from the project root where conf/app.ini lives
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+
+	setting.Setup()
+	models.Setup()
+	logging.Setup()
+	gredis.Setup()
+	util.Setup()
+	storage.Setup()
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Fatalf("blogctl %s: %v", os.Args[1], err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: blogctl <command> [flags]")
+	for name, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, cmd.usage)
+	}
+	os.Exit(2)
+}
+
+func runStatic(args []string) error {
+	fs := flag.NewFlagSet("static", flag.ExitOnError)
+	out := fs.String("out", "public", "output directory")
+	baseUrl := fs.String("base-url", "", "public URL of the mirror, defaults to app.PrefixUrl")
+	fs.Parse(args)
+
+	if *baseUrl != "" {
+		setting.AppSetting.PrefixUrl = strings.TrimSuffix(*baseUrl, "/")
+	}
+
+	export := static_service.Export{Dir: *out}
+	report, err := export.Run()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[info] static site in %s: %d written, %d unchanged, %d removed",
+		*out, report.Written, report.Unchanged, report.Removed)
+	return nil
+}
+
+func runImportMarkdown(args []string) error {
+	fs := flag.NewFlagSet("import-markdown", flag.ExitOnError)
+	author := fs.String("author", "admin", "author of posts without one in their front matter")
+	dryRun := fs.Bool("dry-run", false, "only report what would be imported")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected one directory or zip archive, got %d arguments", fs.NArg())
+	}
+
+	source, err := openSource(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	importer := import_service.Markdown{Source: source, CreatedBy: *author, DryRun: *dryRun}
+	report, err := importer.Run()
+	if err != nil {
+		return err
+	}
+
+	return printReport(report)
+}
+
+func runImportWXR(args []string) error {
+	fs := flag.NewFlagSet("import-wxr", flag.ExitOnError)
+	author := fs.String("author", "admin", "author of posts whose creator is unknown")
+	uploads := fs.String("uploads", "", "local copy of wp-content/uploads to take media from")
+	dryRun := fs.Bool("dry-run", false, "only report what would be imported")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected one WXR file, got %d arguments", fs.NArg())
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	importer := import_service.WordPress{UploadsDir: *uploads, CreatedBy: *author, DryRun: *dryRun}
+	report, err := importer.Run(f)
+	if err != nil {
+		return err
+	}
+
+	return printReport(report)
+}
+
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	out := fs.String("out", backup_service.GetBackupFullPath(), "output directory")
+	accounts := fs.Bool("accounts", false, "also back up the accounts, their passwords in clear")
+	fs.Parse(args)
+
+	backup := backup_service.Backup{Dir: *out, Accounts: *accounts}
+	name, err := backup.Run("", nil)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[info] backup written to %s", filepath.Join(*out, name))
+	return nil
+}
+
+func runMediaRefs(args []string) error {
+	fs := flag.NewFlagSet("media-refs", flag.ExitOnError)
+	fs.Parse(args)
+
+	count, err := media_service.RebuildReferences()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[info] media references of %d articles recorded", count)
+	return nil
+}
+
+func runGc(args []string) error {
+	fs := flag.NewFlagSet("gc", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only report what would be quarantined and purged")
+	restore := fs.String("restore", "", "move a quarantined file back, by its path in the report")
+	fs.Parse(args)
+
+	if *restore != "" {
+		if err := gc_service.Restore(*restore); err != nil {
+			return err
+		}
+
+		log.Printf("[info] %s restored", *restore)
+		return nil
+	}
+
+	collector := gc_service.Collector{DryRun: *dryRun}
+	report, err := collector.Run()
+	if err != nil {
+		return err
+	}
+	if err := printJSON(report); err != nil {
+		return err
+	}
+
+	log.Printf("[info] %d scanned, %d quarantined (%d bytes), %d purged, %d failed",
+		report.Scanned, len(report.Quarantined), report.Bytes, len(report.Purged), len(report.Failed))
+	return nil
+}
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	overwrite := fs.Bool("overwrite", false, "replace existing data instead of requiring an empty database")
+	settings := fs.Bool("settings", false, "also restore conf/app.ini, keeping the current one as conf/app.ini.bak")
+	accounts := fs.Bool("accounts", false, "replace the accounts with those of the archive")
+	verify := fs.Bool("verify", false, "only check the archive against its manifest")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected one backup archive, got %d arguments", fs.NArg())
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if *verify {
+		manifest, err := backup_service.Verify(f, info.Size())
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[info] backup is valid: version %d, %d tables, %d images, %d attachments, %d files",
+			manifest.Version, len(manifest.Tables), manifest.Images, manifest.Attachments, len(manifest.Files))
+		return nil
+	}
+
+	restore := backup_service.Restore{Overwrite: *overwrite, Settings: *settings, Accounts: *accounts}
+	report, err := restore.Run(f, info.Size())
+	if err != nil {
+		return err
+	}
+
+	return printJSON(report)
+}
+
+// openSource opens a zip archive or, for anything else, a directory
+func openSource(path string) (import_service.Source, error) {
+	if !strings.HasSuffix(strings.ToLower(path), ".zip") {
+		return import_service.OpenDir(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return import_service.OpenZip(f, info.Size())
+}
+
+func printReport(report *import_service.Report) error {
+	if err := printJSON(report); err != nil {
+		return err
+	}
+
+	log.Printf("[info] %d imported, %d skipped, %d failed", report.Imported, report.Skipped, report.Failed)
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
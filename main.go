@@ -10,9 +10,18 @@ import (
 	"github.com/EDDYCJY/go-gin-example/models"
 	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
 	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/schedule"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/pkg/theme"
 	"github.com/EDDYCJY/go-gin-example/routers"
 	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/gc_service"
+	"github.com/EDDYCJY/go-gin-example/service/job_service"
+	"github.com/EDDYCJY/go-gin-example/service/reaction_service"
+	"github.com/EDDYCJY/go-gin-example/service/related_service"
+	"github.com/EDDYCJY/go-gin-example/service/sitemap_service"
+	"github.com/EDDYCJY/go-gin-example/service/tus_service"
 )
 
 func init() {
@@ -21,6 +30,8 @@ func init() {
 	logging.Setup()
 	gredis.Setup()
 	util.Setup()
+	storage.Setup()
+	theme.Setup()
 }
 
 // @title Golang Gin API
@@ -46,6 +57,16 @@ func main() {
 		MaxHeaderBytes: maxHeaderBytes,
 	}
 
+	schedule.Every("reaction reconcile", setting.ReactionSetting.ReconcileInterval, reaction_service.Reconcile)
+	schedule.Now("related refresh", related_service.Refresh)
+	schedule.Every("related refresh", setting.RelatedSetting.RefreshInterval, related_service.Refresh)
+	schedule.Every("sitemap refresh", setting.SitemapSetting.RefreshInterval, sitemap_service.Refresh)
+	schedule.Every("export janitor", setting.ExportSetting.JanitorInterval, job_service.Clean)
+	schedule.Every("upload gc", setting.GcSetting.Interval, gc_service.Collect)
+	schedule.Every("tus janitor", setting.TusSetting.JanitorInterval, tus_service.Clean)
+
+	job_service.Setup()
+
 	log.Printf("[info] start http server listening %s", endPoint)
 
 	server.ListenAndServe()
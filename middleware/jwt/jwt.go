@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
@@ -10,6 +11,9 @@ import (
 	"github.com/EDDYCJY/go-gin-example/pkg/util"
 )
 
+// ClaimsKey is the context key under which the parsed claims are stored
+const ClaimsKey = "claims"
+
 // JWT is jwt middleware
 func JWT() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -17,11 +21,11 @@ func JWT() gin.HandlerFunc {
 		var data interface{}
 
 		code = e.SUCCESS
-		token := c.Query("token")
+		token := getToken(c)
 		if token == "" {
 			code = e.INVALID_PARAMS
 		} else {
-			_, err := util.ParseToken(token)
+			claims, err := util.ParseToken(token)
 			if err != nil {
 				switch err.(*jwt.ValidationError).Errors {
 				case jwt.ValidationErrorExpired:
@@ -29,6 +33,8 @@ func JWT() gin.HandlerFunc {
 				default:
 					code = e.ERROR_AUTH_CHECK_TOKEN_FAIL
 				}
+			} else {
+				c.Set(ClaimsKey, claims)
 			}
 		}
 
@@ -46,3 +52,55 @@ func JWT() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalJWT stores the claims of a valid token but lets anonymous requests through
+func OptionalJWT() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := getToken(c); token != "" {
+			if claims, err := util.ParseToken(token); err == nil {
+				c.Set(ClaimsKey, claims)
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// Admin lets through the requests of administrators only. It follows JWT
+func Admin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims, ok := GetClaims(c); !ok || !claims.IsAdmin() {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code": e.ERROR_AUTH_FORBIDDEN,
+				"msg":  e.GetMsg(e.ERROR_AUTH_FORBIDDEN),
+				"data": nil,
+			})
+
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// getToken reads the token from the query, or from an Authorization header for
+// clients such as tus uploaders that follow URLs handed to them
+func getToken(c *gin.Context) string {
+	if token := c.Query("token"); token != "" {
+		return token
+	}
+
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+// GetClaims returns the claims stored by JWT or OptionalJWT
+func GetClaims(c *gin.Context) (*util.Claims, bool) {
+	value, exists := c.Get(ClaimsKey)
+	if !exists {
+		return nil, false
+	}
+
+	claims, ok := value.(*util.Claims)
+	return claims, ok
+}
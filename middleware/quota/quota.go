@@ -0,0 +1,45 @@
+package quota
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/middleware/jwt"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/service/quota_service"
+)
+
+// RateLimit is upload rate limiting middleware, to be used after JWT
+func RateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := GetUser(c)
+		if ok, wait := user.Allow(); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"code": e.ERROR_UPLOAD_RATE_LIMIT,
+				"msg":  e.GetMsg(e.ERROR_UPLOAD_RATE_LIMIT),
+				"data": nil,
+			})
+
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetUser returns the account behind a request that passed JWT
+func GetUser(c *gin.Context) *quota_service.User {
+	user := &quota_service.User{}
+	if claims, ok := jwt.GetClaims(c); ok {
+		user.Owner = claims.Username
+		user.Role = claims.Role
+		user.Name = claims.Name
+	}
+
+	return user
+}
@@ -0,0 +1,27 @@
+package tus
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VERSION is the version of the tus resumable upload protocol spoken
+const VERSION = "1.0.0"
+
+// Resumable is tus middleware, answering with the protocol version and
+// refusing requests of clients speaking another version
+func Resumable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Tus-Resumable", VERSION)
+
+		// OPTIONS is how a client finds out the version to speak
+		if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != VERSION {
+			c.Header("Tus-Version", VERSION)
+			c.AbortWithStatus(http.StatusPreconditionFailed)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// ApiKey is a long-lived key an account signs in to WebDAV with, in place of
+// its password. Only the SHA-256 of the key is kept
+type ApiKey struct {
+	Model
+
+	Username   string `json:"username"`
+	Name       string `json:"name"`
+	KeyHash    string `json:"-"`
+	LastUsedOn int    `json:"last_used_on"`
+}
+
+// GetApiKeys gets the API keys of an account, in the order they were created
+func GetApiKeys(username string) ([]*ApiKey, error) {
+	var keys []*ApiKey
+	err := db.Where("username = ? AND deleted_on = ?", username, 0).Order("id").Find(&keys).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// GetApiKey Get a single API key based on ID
+func GetApiKey(id int) (*ApiKey, error) {
+	var key ApiKey
+	err := db.Where("id = ? AND deleted_on = ? ", id, 0).First(&key).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// GetApiKeyByHash gets the live API key with the hash
+func GetApiKeyByHash(hash string) (*ApiKey, error) {
+	var key ApiKey
+	err := db.Where("key_hash = ? AND deleted_on = ? ", hash, 0).First(&key).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// AddApiKey records an API key
+func AddApiKey(key *ApiKey) error {
+	return db.Create(key).Error
+}
+
+// DeleteApiKey delete a single API key
+func DeleteApiKey(id int) error {
+	return db.Where("id = ?", id).Delete(&ApiKey{}).Error
+}
+
+// UseApiKey records when an API key was last used
+func UseApiKey(id, usedOn int) error {
+	return db.Model(&ApiKey{}).Where("id = ?", id).UpdateColumn("last_used_on", usedOn).Error
+}
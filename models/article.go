@@ -17,6 +17,9 @@ type Article struct {
 	CreatedBy     string `json:"created_by"`
 	ModifiedBy    string `json:"modified_by"`
 	State         int    `json:"state"`
+
+	Reactions map[string]int `json:"reactions" gorm:"-"`
+	Series    *SeriesNav     `json:"series,omitempty" gorm:"-"`
 }
 
 // ExistArticleByID checks if an article exists based on ID
@@ -55,6 +58,61 @@ func GetArticles(pageNum int, pageSize int, maps interface{}) ([]*Article, error
 	return articles, nil
 }
 
+// GetAllArticles gets every article matching the constraints without paging
+func GetAllArticles(maps interface{}) ([]*Article, error) {
+	var articles []*Article
+	err := db.Preload("Tag").Where(maps).Order("id").Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// GetArticlesAfter gets up to limit articles matching the constraints with an ID above lastID, in ID order
+func GetArticlesAfter(lastID, limit int, maps interface{}) ([]*Article, error) {
+	var articles []*Article
+	err := db.Preload("Tag").Where(maps).Where("id > ?", lastID).Order("id").Limit(limit).Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// GetLatestArticles gets a page of articles matching the constraints, newest first
+func GetLatestArticles(pageNum int, pageSize int, maps interface{}) ([]*Article, error) {
+	var articles []*Article
+	err := db.Preload("Tag").Where(maps).Order("created_on DESC, id DESC").Offset(pageNum).Limit(pageSize).Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// GetArticlesByIDRange gets the articles matching the constraints with minID < id <= maxID
+func GetArticlesByIDRange(minID, maxID int, maps interface{}) ([]*Article, error) {
+	var articles []*Article
+	err := db.Preload("Tag").Where(maps).Where("id > ? AND id <= ?", minID, maxID).Order("id").Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// GetMaxArticleID gets the highest article ID, 0 when there are no articles
+func GetMaxArticleID() (int, error) {
+	var article Article
+	err := db.Select("id").Order("id DESC").First(&article).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+
+	return article.ID, nil
+}
+
 // GetArticle Get a single article based on ID
 func GetArticle(id int) (*Article, error) {
 	var article Article
@@ -80,8 +138,28 @@ func EditArticle(id int, data interface{}) error {
 	return nil
 }
 
-// AddArticle add a single article
-func AddArticle(data map[string]interface{}) error {
+// AddArticle add a single article and returns its ID
+func AddArticle(data map[string]interface{}) (int, error) {
+	article := newArticle(data)
+	if err := db.Create(&article).Error; err != nil {
+		return 0, err
+	}
+
+	return article.ID, nil
+}
+
+// AddImportedArticle adds an article and records the item it was imported
+// from in one transaction, so that an import run again never duplicates it
+func AddImportedArticle(data map[string]interface{}, source, guid, kind string) (int, error) {
+	article := newArticle(data)
+	if err := addImported(&article, func() int { return article.ID }, source, guid, kind); err != nil {
+		return 0, err
+	}
+
+	return article.ID, nil
+}
+
+func newArticle(data map[string]interface{}) Article {
 	article := Article{
 		TagID:         data["tag_id"].(int),
 		Title:         data["title"].(string),
@@ -91,11 +169,11 @@ func AddArticle(data map[string]interface{}) error {
 		State:         data["state"].(int),
 		CoverImageUrl: data["cover_image_url"].(string),
 	}
-	if err := db.Create(&article).Error; err != nil {
-		return err
+	if createdOn, ok := data["created_on"].(int); ok {
+		article.CreatedOn = createdOn
 	}
 
-	return nil
+	return article
 }
 
 // DeleteArticle delete a single article
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Attachment is a file attached to an article, stored under the hash of its
+// content like media
+type Attachment struct {
+	Model
+
+	ArticleID     int    `json:"article_id" gorm:"index"`
+	Hash          string `json:"hash"`
+	Name          string `json:"name"`
+	OriginalName  string `json:"original_name"`
+	Type          string `json:"type"`
+	ContentType   string `json:"content_type"`
+	Size          int    `json:"size"`
+	DownloadCount int    `json:"download_count"`
+	Owner         string `json:"owner"`
+	CreatedBy     string `json:"created_by"`
+}
+
+// ExistAttachmentByID checks if an attachment exists based on ID
+func ExistAttachmentByID(id int) (bool, error) {
+	var attachment Attachment
+	err := db.Select("id").Where("id = ? AND deleted_on = ? ", id, 0).First(&attachment).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	if attachment.ID > 0 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// GetAttachments gets the attachments of an article, in the order they were added
+func GetAttachments(articleID int) ([]*Attachment, error) {
+	var attachments []*Attachment
+	err := db.Where("article_id = ? AND deleted_on = ?", articleID, 0).Order("id").Find(&attachments).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment Get a single attachment based on ID
+func GetAttachment(id int) (*Attachment, error) {
+	var attachment Attachment
+	err := db.Where("id = ? AND deleted_on = ? ", id, 0).First(&attachment).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// ExistAttachmentByName checks if any attachment still uses a stored file
+func ExistAttachmentByName(name string) (bool, error) {
+	var count int
+	err := db.Model(&Attachment{}).Where("name = ? AND deleted_on = ?", name, 0).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// AddAttachment records an attached file
+func AddAttachment(attachment *Attachment) error {
+	return db.Create(attachment).Error
+}
+
+// DeleteAttachment delete a single attachment
+func DeleteAttachment(id int) error {
+	if err := db.Where("id = ?", id).Delete(&Attachment{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SumAttachmentSize sums the size of the live attachments an account uploaded
+func SumAttachmentSize(owner string) (int64, error) {
+	var size int64
+	row := db.Model(&Attachment{}).Select("COALESCE(SUM(size), 0)").Where("owner = ? AND deleted_on = ?", owner, 0).Row()
+	if err := row.Scan(&size); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// AddAttachmentDownload counts a download of an attachment
+func AddAttachmentDownload(id int) error {
+	return db.Model(&Attachment{}).Where("id = ?", id).
+		UpdateColumn("download_count", gorm.Expr("download_count + 1")).Error
+}
@@ -6,6 +6,7 @@ type Auth struct {
 	ID       int    `gorm:"primary_key" json:"id"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Role     string `json:"role"`
 }
 
 // CheckAuth checks if authentication information exists
@@ -22,3 +23,14 @@ func CheckAuth(username, password string) (bool, error) {
 
 	return false, nil
 }
+
+// GetAuthRole gets the role of an account, empty when it has none
+func GetAuthRole(username string) (string, error) {
+	var auth Auth
+	err := db.Select("role").Where(Auth{Username: username}).First(&auth).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return "", err
+	}
+
+	return auth.Role, nil
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// BackupTables are the tables a backup holds, without the table prefix, in the
+// order they are restored
+var BackupTables = []string{
+	"tag",
+	"article",
+	"article_reaction",
+	"comment",
+	"series",
+	"series_article",
+	"import_source",
+	"media",
+	"media_reference",
+	"attachment",
+}
+
+// AccountTables are the tables of the accounts. As the passwords are kept in
+// clear, a backup only holds them and a restore only replaces them when asked
+var AccountTables = []string{
+	"auth",
+	"api_key",
+}
+
+var (
+	ErrUnknownTable = errors.New("unknown table")
+
+	columnRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+func prefixTable(name string) string {
+	return setting.DatabaseSetting.TablePrefix + name
+}
+
+// ExistTable checks if a table has been created
+func ExistTable(name string) bool {
+	return db.HasTable(prefixTable(name))
+}
+
+// CreateTable creates a table of BackupTables or AccountTables from its known schema
+func CreateTable(name string) error {
+	columns, ok := tableSchemas[name]
+	if !ok {
+		return ErrUnknownTable
+	}
+
+	return db.Exec("CREATE TABLE `" + prefixTable(name) + "` (\n  " + strings.Join(columns, ",\n  ") +
+		"\n) ENGINE=InnoDB DEFAULT CHARSET=utf8").Error
+}
+
+// CountRows counts every row of a table, soft deleted ones included
+func CountRows(name string) (int, error) {
+	var count int
+	if err := db.Table(prefixTable(name)).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// EachRow reads every row of a table in ID order, soft deleted ones included,
+// as a map of column to value
+func EachRow(name string, fn func(row map[string]interface{}) error) error {
+	rows, err := db.Table(prefixTable(name)).Order("id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[column] = string(b)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// TableRestore writes rows back into tables within one transaction
+type TableRestore struct {
+	tx *gorm.DB
+}
+
+// BeginRestore starts a restore
+func BeginRestore() *TableRestore {
+	return &TableRestore{tx: db.Begin()}
+}
+
+// Empty deletes every row of a table
+func (r *TableRestore) Empty(name string) error {
+	return r.tx.Exec("DELETE FROM `" + prefixTable(name) + "`").Error
+}
+
+// Insert adds a row as read by EachRow
+func (r *TableRestore) Insert(name string, row map[string]interface{}) error {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		if !columnRegexp.MatchString(column) {
+			return errors.New("invalid column name: " + column)
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return errors.New("empty row")
+	}
+	sort.Strings(columns)
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = row[column]
+	}
+
+	sql := "INSERT INTO `" + prefixTable(name) + "` (`" + strings.Join(columns, "`, `") + "`) VALUES (?" +
+		strings.Repeat(", ?", len(columns)-1) + ")"
+	return r.tx.Exec(sql, values...).Error
+}
+
+func (r *TableRestore) Commit() error {
+	return r.tx.Commit().Error
+}
+
+func (r *TableRestore) Rollback() error {
+	return r.tx.Rollback().Error
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+type Comment struct {
+	Model
+
+	ArticleID int    `json:"article_id" gorm:"index"`
+	ParentID  int    `json:"parent_id"`
+	Author    string `json:"author"`
+	Email     string `json:"-"`
+	Url       string `json:"url"`
+	Content   string `json:"content"`
+	State     int    `json:"state"`
+}
+
+// AddComment add a single comment and returns its ID
+func AddComment(data map[string]interface{}) (int, error) {
+	comment := newComment(data)
+	if err := db.Create(&comment).Error; err != nil {
+		return 0, err
+	}
+
+	return comment.ID, nil
+}
+
+// AddImportedComment adds a comment and records the item it was imported
+// from in one transaction, so that an import run again never duplicates it
+func AddImportedComment(data map[string]interface{}, source, guid, kind string) (int, error) {
+	comment := newComment(data)
+	if err := addImported(&comment, func() int { return comment.ID }, source, guid, kind); err != nil {
+		return 0, err
+	}
+
+	return comment.ID, nil
+}
+
+func newComment(data map[string]interface{}) Comment {
+	comment := Comment{
+		ArticleID: data["article_id"].(int),
+		ParentID:  data["parent_id"].(int),
+		Author:    data["author"].(string),
+		Email:     data["email"].(string),
+		Url:       data["url"].(string),
+		Content:   data["content"].(string),
+		State:     data["state"].(int),
+	}
+	if createdOn, ok := data["created_on"].(int); ok {
+		comment.CreatedOn = createdOn
+	}
+
+	return comment
+}
+
+// GetComments gets the comments of an article in the order they were written
+func GetComments(articleID int, maps interface{}) ([]*Comment, error) {
+	var comments []*Comment
+	err := db.Where("article_id = ? AND deleted_on = ?", articleID, 0).Where(maps).Order("created_on, id").Find(&comments).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// DeleteArticleComments deletes every comment of an article
+func DeleteArticleComments(articleID int) error {
+	if err := db.Where("article_id = ?", articleID).Delete(Comment{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// ImportSource remembers which record an imported item became, keyed by the
+// identifier the originating system gave it, so that imports can be re-run
+type ImportSource struct {
+	ID        int    `gorm:"primary_key" json:"id"`
+	Source    string `json:"source" gorm:"unique_index:uix_import_source"`
+	GUID      string `json:"guid" gorm:"column:guid;unique_index:uix_import_source"`
+	Kind      string `json:"kind"`
+	TargetID  int    `json:"target_id"`
+	CreatedOn int    `json:"created_on"`
+}
+
+// GetImportTarget returns the ID of the record an item was imported as, or 0 if it was not
+func GetImportTarget(source, guid string) (int, error) {
+	var importSource ImportSource
+	err := db.Select("target_id").Where("source = ? AND guid = ?", source, guid).First(&importSource).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+
+	return importSource.TargetID, nil
+}
+
+// addImported creates record together with the import source pointing at it
+func addImported(record interface{}, getID func() int, source, guid, kind string) error {
+	tx := db.Begin()
+	if err := tx.Create(record).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	importSource := ImportSource{
+		Source:   source,
+		GUID:     guid,
+		Kind:     kind,
+		TargetID: getID(),
+	}
+	if err := tx.Create(&importSource).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,295 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Media is an uploaded file, stored once under the hash of its content however
+// many times and names it is uploaded with
+type Media struct {
+	Model
+
+	Hash         string `json:"hash" gorm:"unique_index:uix_media_hash"`
+	Name         string `json:"name"`
+	OriginalName string `json:"original_name"`
+	ContentType  string `json:"content_type"`
+	Size         int    `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	AltText      string `json:"alt_text"`
+	Caption      string `json:"caption"`
+	Owner        string `json:"owner"`
+	CreatedBy    string `json:"created_by"`
+
+	// References counts the articles using the media
+	References int `json:"references" gorm:"-"`
+}
+
+// MediaReference records that an article uses a media, as its cover image or
+// through a link in its content
+type MediaReference struct {
+	ID        int    `gorm:"primary_key" json:"id"`
+	MediaID   int    `json:"media_id" gorm:"index"`
+	ArticleID int    `json:"article_id" gorm:"index"`
+	Source    string `json:"source"`
+}
+
+const (
+	MEDIA_SOURCE_COVER   = "cover"
+	MEDIA_SOURCE_CONTENT = "content"
+)
+
+// ExistMediaByID checks if a media exists based on ID
+func ExistMediaByID(id int) (bool, error) {
+	var media Media
+	err := db.Select("id").Where("id = ? AND deleted_on = ? ", id, 0).First(&media).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	if media.ID > 0 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// GetMediaTotal counts the media matching the constraints and the keyword
+func GetMediaTotal(maps interface{}, keyword string) (int, error) {
+	var count int
+	if err := searchMedia(db.Model(&Media{}).Where(maps), keyword).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetMediaList gets a page of the media matching the constraints and the keyword, newest first
+func GetMediaList(pageNum int, pageSize int, maps interface{}, keyword string) ([]*Media, error) {
+	var media []*Media
+	err := searchMedia(db.Where(maps), keyword).Order("id DESC").Offset(pageNum).Limit(pageSize).Find(&media).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// GetAllMedia gets all the media matching the constraints, oldest first
+func GetAllMedia(maps interface{}) ([]*Media, error) {
+	var media []*Media
+	err := db.Where(maps).Order("id").Find(&media).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// GetMediaNames gets the storage names of every live media
+func GetMediaNames() ([]string, error) {
+	var names []string
+	if err := db.Model(&Media{}).Where("deleted_on = ?", 0).Pluck("name", &names).Error; err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, whose default escape character is the backslash
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// searchMedia narrows a query to the media whose file name, alt text or caption contains the keyword
+func searchMedia(query *gorm.DB, keyword string) *gorm.DB {
+	if keyword == "" {
+		return query
+	}
+
+	like := "%" + likeEscaper.Replace(keyword) + "%"
+	return query.Where("original_name LIKE ? OR alt_text LIKE ? OR caption LIKE ?", like, like, like)
+}
+
+// GetMedia Get a single media based on ID
+func GetMedia(id int) (*Media, error) {
+	var media Media
+	err := db.Where("id = ? AND deleted_on = ? ", id, 0).First(&media).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return &media, nil
+}
+
+// GetMediaByNames gets the media stored under any of the names
+func GetMediaByNames(names []string) ([]*Media, error) {
+	var media []*Media
+	if len(names) == 0 {
+		return media, nil
+	}
+
+	err := db.Where("name IN (?) AND deleted_on = ?", names, 0).Find(&media).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// GetMediaByOriginalName gets the newest live media uploaded under the file name, or nil if there is none
+func GetMediaByOriginalName(name string) (*Media, error) {
+	var media Media
+	err := db.Where("original_name = ? AND deleted_on = ?", name, 0).Order("id DESC").First(&media).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &media, nil
+}
+
+// GetMediaByHash gets the media with the content hash, deleted or not, or nil if there is none
+func GetMediaByHash(hash string) (*Media, error) {
+	var media Media
+	err := db.Where("hash = ?", hash).First(&media).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &media, nil
+}
+
+// GetMediaByName gets the media stored under the name, deleted or not, or nil if there is none
+func GetMediaByName(name string) (*Media, error) {
+	var media Media
+	err := db.Where("name = ?", name).First(&media).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &media, nil
+}
+
+// AddMedia records a stored file
+func AddMedia(media *Media) error {
+	return db.Create(media).Error
+}
+
+// EditMedia modify a single media
+func EditMedia(id int, data interface{}) error {
+	if err := db.Model(&Media{}).Where("id = ? AND deleted_on = ? ", id, 0).Updates(data).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUnusedMedia deletes a media unless an article uses it, checking and
+// deleting in one statement so that no reference is recorded in between. It
+// reports whether the media was deleted
+func DeleteUnusedMedia(id int) (bool, error) {
+	result := db.Where("id = ? AND deleted_on = ?", id, 0).
+		Where("NOT EXISTS (SELECT 1 FROM `"+prefixTable("media_reference")+"` WHERE media_id = ?)", id).
+		Delete(&Media{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// RestoreMedia undeletes a media, as when its content is uploaded again
+func RestoreMedia(id int) error {
+	return db.Model(&Media{}).Where("id = ?", id).Update("deleted_on", 0).Error
+}
+
+// SumMediaSize sums the size of the live media an account uploaded
+func SumMediaSize(owner string) (int64, error) {
+	var size int64
+	row := db.Model(&Media{}).Select("COALESCE(SUM(size), 0)").Where("owner = ? AND deleted_on = ?", owner, 0).Row()
+	if err := row.Scan(&size); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// CountMediaReferences counts the articles using each of the media
+func CountMediaReferences(ids []int) (map[int]int, error) {
+	counts := make(map[int]int)
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	rows, err := db.Model(&MediaReference{}).
+		Select("media_id, COUNT(DISTINCT article_id)").
+		Where("media_id IN (?)", ids).
+		Group("media_id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// GetMediaArticles gets the articles using a media
+func GetMediaArticles(mediaID int) ([]*Article, error) {
+	var ids []int
+	err := db.Model(&MediaReference{}).Where("media_id = ?", mediaID).Pluck("DISTINCT article_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var articles []*Article
+	if len(ids) == 0 {
+		return articles, nil
+	}
+	err = db.Where("id IN (?) AND deleted_on = ?", ids, 0).Order("id").Find(&articles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// SetArticleMediaReferences replaces the media references of an article
+func SetArticleMediaReferences(articleID int, references []MediaReference) error {
+	tx := db.Begin()
+	if err := tx.Where("article_id = ?", articleID).Delete(&MediaReference{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, reference := range references {
+		reference.ID = 0
+		reference.ArticleID = articleID
+		if err := tx.Create(&reference).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// DeleteArticleMediaReferences drops the media references of a deleted article
+func DeleteArticleMediaReferences(articleID int) error {
+	return db.Where("article_id = ?", articleID).Delete(&MediaReference{}).Error
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+type ArticleReaction struct {
+	ID        int    `gorm:"primary_key" json:"id"`
+	ArticleID int    `json:"article_id" gorm:"unique_index:uix_article_reaction"`
+	Kind      string `json:"kind" gorm:"unique_index:uix_article_reaction"`
+	Actor     string `json:"actor" gorm:"unique_index:uix_article_reaction"`
+	CreatedOn int    `json:"created_on"`
+}
+
+// ExistArticleReaction checks if an actor has already reacted to an article with the kind
+func ExistArticleReaction(articleID int, kind, actor string) (bool, error) {
+	var reaction ArticleReaction
+	err := db.Select("id").Where("article_id = ? AND kind = ? AND actor = ?", articleID, kind, actor).First(&reaction).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	if reaction.ID > 0 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// AddArticleReaction records a reaction, reporting false if the actor had already reacted
+func AddArticleReaction(articleID int, kind, actor string) (bool, error) {
+	exists, err := ExistArticleReaction(articleID, kind, actor)
+	if err != nil || exists {
+		return false, err
+	}
+
+	reaction := ArticleReaction{
+		ArticleID: articleID,
+		Kind:      kind,
+		Actor:     actor,
+	}
+	if err := db.Create(&reaction).Error; err != nil {
+		// a concurrent request may have won the race on the unique index
+		if exists, _ := ExistArticleReaction(articleID, kind, actor); exists {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DeleteArticleReaction removes a reaction, reporting false if there was nothing to remove
+func DeleteArticleReaction(articleID int, kind, actor string) (bool, error) {
+	result := db.Where("article_id = ? AND kind = ? AND actor = ?", articleID, kind, actor).Delete(&ArticleReaction{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// GetArticleReactionCounts counts reactions per kind for each of the given articles
+func GetArticleReactionCounts(articleIDs []int) (map[int]map[string]int, error) {
+	var rows []struct {
+		ArticleID int
+		Kind      string
+		Total     int
+	}
+
+	counts := make(map[int]map[string]int, len(articleIDs))
+	for _, id := range articleIDs {
+		counts[id] = make(map[string]int)
+	}
+	if len(articleIDs) == 0 {
+		return counts, nil
+	}
+
+	err := db.Model(&ArticleReaction{}).
+		Select("article_id, kind, COUNT(*) AS total").
+		Where("article_id IN (?)", articleIDs).
+		Group("article_id, kind").
+		Scan(&rows).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ArticleID][row.Kind] = row.Total
+	}
+
+	return counts, nil
+}
+
+// DeleteArticleReactions removes all the reactions to an article
+func DeleteArticleReactions(articleID int) error {
+	if err := db.Where("article_id = ?", articleID).Delete(ArticleReaction{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package models
+
+// tableSchemas are the columns and keys of the tables a backup may hold, as in
+// docs/sql/blog.sql. A restore creates the missing tables from them rather than
+// from statements read out of the archive
+var tableSchemas = map[string][]string{
+	"article": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`tag_id` int(10) unsigned DEFAULT '0' COMMENT '标签ID'",
+		"`title` varchar(100) DEFAULT '' COMMENT '文章标题'",
+		"`desc` varchar(255) DEFAULT '' COMMENT '简述'",
+		"`content` text COMMENT '内容'",
+		"`cover_image_url` varchar(255) DEFAULT '' COMMENT '封面图片地址'",
+		"`created_on` int(10) unsigned DEFAULT '0' COMMENT '新建时间'",
+		"`created_by` varchar(100) DEFAULT '' COMMENT '创建人'",
+		"`modified_on` int(10) unsigned DEFAULT '0' COMMENT '修改时间'",
+		"`modified_by` varchar(255) DEFAULT '' COMMENT '修改人'",
+		"`deleted_on` int(10) unsigned DEFAULT '0'",
+		"`state` tinyint(3) unsigned DEFAULT '1' COMMENT '删除时间'",
+		"PRIMARY KEY (`id`)",
+	},
+	"tag": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`name` varchar(100) DEFAULT '' COMMENT '标签名称'",
+		"`created_on` int(10) unsigned DEFAULT '0' COMMENT '创建时间'",
+		"`created_by` varchar(100) DEFAULT '' COMMENT '创建人'",
+		"`modified_on` int(10) unsigned DEFAULT '0' COMMENT '修改时间'",
+		"`modified_by` varchar(100) DEFAULT '' COMMENT '修改人'",
+		"`deleted_on` int(10) unsigned DEFAULT '0' COMMENT '删除时间'",
+		"`state` tinyint(3) unsigned DEFAULT '1' COMMENT '状态 0为禁用、1为启用'",
+		"PRIMARY KEY (`id`)",
+	},
+	"article_reaction": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`article_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '文章ID'",
+		"`kind` varchar(20) NOT NULL DEFAULT '' COMMENT '表态类型'",
+		"`actor` varchar(64) NOT NULL DEFAULT '' COMMENT '用户或匿名指纹'",
+		"`created_on` int(10) unsigned DEFAULT '0' COMMENT '创建时间'",
+		"PRIMARY KEY (`id`)",
+		"UNIQUE KEY `uix_article_reaction` (`article_id`,`kind`,`actor`)",
+	},
+	"series": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`title` varchar(100) DEFAULT '' COMMENT '系列标题'",
+		"`desc` varchar(255) DEFAULT '' COMMENT '简述'",
+		"`created_on` int(10) unsigned DEFAULT '0' COMMENT '创建时间'",
+		"`created_by` varchar(100) DEFAULT '' COMMENT '创建人'",
+		"`modified_on` int(10) unsigned DEFAULT '0' COMMENT '修改时间'",
+		"`modified_by` varchar(100) DEFAULT '' COMMENT '修改人'",
+		"`deleted_on` int(10) unsigned DEFAULT '0' COMMENT '删除时间'",
+		"`state` tinyint(3) unsigned DEFAULT '1' COMMENT '状态 0为禁用、1为启用'",
+		"PRIMARY KEY (`id`)",
+	},
+	"series_article": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`series_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '系列ID'",
+		"`article_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '文章ID'",
+		"`position` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '系列内顺序，从1开始'",
+		"PRIMARY KEY (`id`)",
+		"UNIQUE KEY `uix_series_article_article_id` (`article_id`)",
+		"KEY `idx_series_article_series_id` (`series_id`)",
+	},
+	"comment": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`article_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '文章ID'",
+		"`parent_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '回复的评论ID'",
+		"`author` varchar(100) DEFAULT '' COMMENT '评论人'",
+		"`email` varchar(100) DEFAULT '' COMMENT '评论人邮箱'",
+		"`url` varchar(255) DEFAULT '' COMMENT '评论人网址'",
+		"`content` text COMMENT '评论内容'",
+		"`created_on` int(10) unsigned DEFAULT '0' COMMENT '创建时间'",
+		"`modified_on` int(10) unsigned DEFAULT '0' COMMENT '修改时间'",
+		"`deleted_on` int(10) unsigned DEFAULT '0' COMMENT '删除时间'",
+		"`state` tinyint(3) unsigned DEFAULT '1' COMMENT '状态 0为待审核、1为已通过'",
+		"PRIMARY KEY (`id`)",
+		"KEY `idx_comment_article_id` (`article_id`)",
+	},
+	"import_source": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`source` varchar(20) NOT NULL DEFAULT '' COMMENT '来源系统'",
+		"`guid` varchar(255) NOT NULL DEFAULT '' COMMENT '来源系统中的唯一标识'",
+		"`kind` varchar(20) NOT NULL DEFAULT '' COMMENT '导入的记录类型'",
+		"`target_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '导入后的记录ID'",
+		"`created_on` int(10) unsigned DEFAULT '0' COMMENT '创建时间'",
+		"PRIMARY KEY (`id`)",
+		"UNIQUE KEY `uix_import_source` (`source`,`guid`)",
+	},
+	"media": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`hash` char(64) NOT NULL DEFAULT '' COMMENT '内容的SHA-256'",
+		"`name` varchar(255) NOT NULL DEFAULT '' COMMENT '存储路径'",
+		"`original_name` varchar(255) NOT NULL DEFAULT '' COMMENT '上传时的文件名'",
+		"`content_type` varchar(100) NOT NULL DEFAULT '' COMMENT '媒体类型'",
+		"`size` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '字节数'",
+		"`width` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '图片宽度'",
+		"`height` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '图片高度'",
+		"`alt_text` varchar(255) DEFAULT '' COMMENT '替代文本'",
+		"`caption` varchar(255) DEFAULT '' COMMENT '说明文字'",
+		"`owner` varchar(50) NOT NULL DEFAULT '' COMMENT '上传账号（用户名的MD5），计入其存储配额'",
+		"`created_on` int(10) unsigned DEFAULT '0' COMMENT '创建时间'",
+		"`created_by` varchar(100) DEFAULT '' COMMENT '上传人'",
+		"`modified_on` int(10) unsigned DEFAULT '0' COMMENT '修改时间'",
+		"`deleted_on` int(10) unsigned DEFAULT '0' COMMENT '删除时间'",
+		"PRIMARY KEY (`id`)",
+		"UNIQUE KEY `uix_media_hash` (`hash`)",
+		"KEY `idx_media_owner` (`owner`)",
+	},
+	"media_reference": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`media_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '媒体文件ID'",
+		"`article_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '文章ID'",
+		"`source` varchar(20) NOT NULL DEFAULT '' COMMENT '引用位置：cover 封面，content 正文'",
+		"PRIMARY KEY (`id`)",
+		"KEY `idx_media_reference_media_id` (`media_id`)",
+		"KEY `idx_media_reference_article_id` (`article_id`)",
+	},
+	"attachment": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`article_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '文章ID'",
+		"`hash` char(64) NOT NULL DEFAULT '' COMMENT '内容的SHA-256'",
+		"`name` varchar(255) NOT NULL DEFAULT '' COMMENT '存储路径'",
+		"`original_name` varchar(255) NOT NULL DEFAULT '' COMMENT '上传时的文件名'",
+		"`type` varchar(50) NOT NULL DEFAULT '' COMMENT '附件类型，如 document、archive、code'",
+		"`content_type` varchar(100) NOT NULL DEFAULT '' COMMENT '媒体类型'",
+		"`size` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '字节数'",
+		"`download_count` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '下载次数'",
+		"`owner` varchar(50) NOT NULL DEFAULT '' COMMENT '上传账号（用户名的MD5），计入其存储配额'",
+		"`created_on` int(10) unsigned DEFAULT '0' COMMENT '创建时间'",
+		"`created_by` varchar(100) DEFAULT '' COMMENT '上传人'",
+		"`modified_on` int(10) unsigned DEFAULT '0' COMMENT '修改时间'",
+		"`deleted_on` int(10) unsigned DEFAULT '0' COMMENT '删除时间'",
+		"PRIMARY KEY (`id`)",
+		"KEY `idx_attachment_article_id` (`article_id`)",
+		"KEY `idx_attachment_owner` (`owner`)",
+	},
+	"auth": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`username` varchar(50) DEFAULT '' COMMENT '账号'",
+		"`password` varchar(50) DEFAULT '' COMMENT '密码'",
+		"`role` varchar(50) DEFAULT '' COMMENT '角色，决定上传配额，为空时使用默认角色'",
+		"PRIMARY KEY (`id`)",
+	},
+	"api_key": {
+		"`id` int(10) unsigned NOT NULL AUTO_INCREMENT",
+		"`username` varchar(50) NOT NULL DEFAULT '' COMMENT '账号'",
+		"`name` varchar(100) NOT NULL DEFAULT '' COMMENT '密钥名称，如使用它的设备'",
+		"`key_hash` char(64) NOT NULL DEFAULT '' COMMENT '密钥的SHA-256，密钥本身不保存'",
+		"`last_used_on` int(10) unsigned DEFAULT '0' COMMENT '最后使用时间'",
+		"`created_on` int(10) unsigned DEFAULT '0' COMMENT '创建时间'",
+		"`modified_on` int(10) unsigned DEFAULT '0' COMMENT '修改时间'",
+		"`deleted_on` int(10) unsigned DEFAULT '0' COMMENT '删除时间'",
+		"PRIMARY KEY (`id`)",
+		"UNIQUE KEY `uix_api_key_key_hash` (`key_hash`)",
+		"KEY `idx_api_key_username` (`username`)",
+	},
+}
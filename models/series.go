@@ -0,0 +1,313 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrSeriesArticlesMismatch is returned when a new order does not list exactly the current parts
+var ErrSeriesArticlesMismatch = errors.New("series articles mismatch")
+
+type Series struct {
+	Model
+
+	Title      string `json:"title"`
+	Desc       string `json:"desc"`
+	CreatedBy  string `json:"created_by"`
+	ModifiedBy string `json:"modified_by"`
+	State      int    `json:"state"`
+}
+
+type SeriesArticle struct {
+	ID        int `gorm:"primary_key" json:"id"`
+	SeriesID  int `json:"series_id" gorm:"index"`
+	ArticleID int `json:"article_id" gorm:"unique_index"`
+	Position  int `json:"position"`
+
+	Article Article `json:"article"`
+}
+
+// SeriesNav places an article within its series
+type SeriesNav struct {
+	ID       int            `json:"id"`
+	Title    string         `json:"title"`
+	Position int            `json:"position"`
+	Total    int            `json:"total"`
+	Prev     *SeriesNavLink `json:"prev"`
+	Next     *SeriesNavLink `json:"next"`
+}
+
+type SeriesNavLink struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// ExistSeriesByID checks if a series exists based on ID
+func ExistSeriesByID(id int) (bool, error) {
+	var series Series
+	err := db.Select("id").Where("id = ? AND deleted_on = ? ", id, 0).First(&series).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+
+	if series.ID > 0 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// GetSeriesTotal counts the total number of series based on the constraint
+func GetSeriesTotal(maps interface{}) (int, error) {
+	var count int
+	if err := db.Model(&Series{}).Where(maps).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetSeriesList gets a list of series based on paging constraints
+func GetSeriesList(pageNum int, pageSize int, maps interface{}) ([]*Series, error) {
+	var series []*Series
+	err := db.Where(maps).Offset(pageNum).Limit(pageSize).Find(&series).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// GetSeries Get a single series based on ID
+func GetSeries(id int) (*Series, error) {
+	var series Series
+	err := db.Where("id = ? AND deleted_on = ? ", id, 0).First(&series).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return &series, nil
+}
+
+// AddSeries add a single series
+func AddSeries(data map[string]interface{}) error {
+	series := Series{
+		Title:     data["title"].(string),
+		Desc:      data["desc"].(string),
+		CreatedBy: data["created_by"].(string),
+		State:     data["state"].(int),
+	}
+	if err := db.Create(&series).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// EditSeries modify a single series
+func EditSeries(id int, data interface{}) error {
+	if err := db.Model(&Series{}).Where("id = ? AND deleted_on = ? ", id, 0).Updates(data).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteSeries delete a single series and release its articles
+func DeleteSeries(id int) error {
+	tx := db.Begin()
+	if err := tx.Where("id = ?", id).Delete(&Series{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("series_id = ?", id).Delete(&SeriesArticle{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// GetSeriesArticles gets the parts of a series in order
+func GetSeriesArticles(seriesID int) ([]*SeriesArticle, error) {
+	var parts []*SeriesArticle
+	err := db.Preload("Article").Where("series_id = ?", seriesID).Order("position").Find(&parts).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return parts, nil
+}
+
+// GetSeriesArticleTotal counts the parts of a series
+func GetSeriesArticleTotal(seriesID int) (int, error) {
+	var count int
+	if err := db.Model(&SeriesArticle{}).Where("series_id = ?", seriesID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetSeriesArticleByArticleID gets the series membership of an article, nil if it belongs to none
+func GetSeriesArticleByArticleID(articleID int) (*SeriesArticle, error) {
+	var part SeriesArticle
+	err := db.Where("article_id = ?", articleID).First(&part).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &part, nil
+}
+
+// GetSeriesArticleByPosition gets the part at a position of a series, nil if there is none
+func GetSeriesArticleByPosition(seriesID, position int) (*SeriesArticle, error) {
+	var part SeriesArticle
+	err := db.Preload("Article").Where("series_id = ? AND position = ?", seriesID, position).First(&part).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &part, nil
+}
+
+// InsertSeriesArticle inserts an article at a 1-based position, shifting later parts back;
+// positions outside the series append the article
+func InsertSeriesArticle(seriesID, articleID, position int) error {
+	tx := db.Begin()
+	if err := lockSeries(tx, seriesID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var count int
+	if err := tx.Model(&SeriesArticle{}).Where("series_id = ?", seriesID).Count(&count).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if position < 1 || position > count+1 {
+		position = count + 1
+	}
+
+	err := tx.Model(&SeriesArticle{}).
+		Where("series_id = ? AND position >= ?", seriesID, position).
+		UpdateColumn("position", gorm.Expr("position + 1")).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	part := SeriesArticle{
+		SeriesID:  seriesID,
+		ArticleID: articleID,
+		Position:  position,
+	}
+	if err := tx.Create(&part).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// RemoveSeriesArticle takes an article out of its series, closing the gap it leaves
+func RemoveSeriesArticle(articleID int) error {
+	tx := db.Begin()
+
+	var part SeriesArticle
+	err := tx.Where("article_id = ?", articleID).First(&part).Error
+	if err == gorm.ErrRecordNotFound {
+		tx.Rollback()
+		return nil
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// the part is read again under the lock, its position may have moved meanwhile
+	if err := lockSeries(tx, part.SeriesID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("id = ?", part.ID).First(&part).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if err := tx.Delete(&part).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Model(&SeriesArticle{}).
+		Where("series_id = ? AND position > ?", part.SeriesID, part.Position).
+		UpdateColumn("position", gorm.Expr("position - 1")).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// SortSeriesArticles renumbers the parts of a series following articleIDs, which
+// must list every current part exactly once
+func SortSeriesArticles(seriesID int, articleIDs []int) error {
+	tx := db.Begin()
+	if err := lockSeries(tx, seriesID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var parts []SeriesArticle
+	if err := tx.Where("series_id = ?", seriesID).Find(&parts).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	members := make(map[int]bool, len(parts))
+	for _, part := range parts {
+		members[part.ArticleID] = true
+	}
+	if len(articleIDs) != len(members) {
+		tx.Rollback()
+		return ErrSeriesArticlesMismatch
+	}
+	for _, id := range articleIDs {
+		if !members[id] {
+			tx.Rollback()
+			return ErrSeriesArticlesMismatch
+		}
+		delete(members, id)
+	}
+
+	for i, id := range articleIDs {
+		err := tx.Model(&SeriesArticle{}).
+			Where("series_id = ? AND article_id = ?", seriesID, id).
+			UpdateColumn("position", i+1).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// lockSeries locks the row of a series until the transaction ends, so that
+// changes to the positions of its parts are made one at a time
+func lockSeries(tx *gorm.DB, seriesID int) error {
+	var series Series
+	return tx.Set("gorm:query_option", "FOR UPDATE").Select("id").Where("id = ?", seriesID).First(&series).Error
+}
@@ -28,18 +28,32 @@ func ExistTagByName(name string) (bool, error) {
 	return false, nil
 }
 
-// AddTag Add a Tag
-func AddTag(name string, state int, createdBy string) error {
+// GetTagByName gets a tag by its exact name, or nil if there is none
+func GetTagByName(name string) (*Tag, error) {
+	var tag Tag
+	err := db.Where("name = ? AND deleted_on = ? ", name, 0).First(&tag).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// AddTag Add a Tag and returns its ID
+func AddTag(name string, state int, createdBy string) (int, error) {
 	tag := Tag{
 		Name:      name,
 		State:     state,
 		CreatedBy: createdBy,
 	}
 	if err := db.Create(&tag).Error; err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return tag.ID, nil
 }
 
 // GetTags gets a list of tags based on paging and constraints
@@ -62,6 +76,17 @@ func GetTags(pageNum int, pageSize int, maps interface{}) ([]Tag, error) {
 	return tags, nil
 }
 
+// GetTagsAfter gets up to limit tags matching the constraints with an ID above lastID, in ID order
+func GetTagsAfter(lastID, limit int, maps interface{}) ([]Tag, error) {
+	var tags []Tag
+	err := db.Where(maps).Where("id > ?", lastID).Order("id").Limit(limit).Find(&tags).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
 // GetTagTotal counts the total number of tags based on the constraint
 func GetTagTotal(maps interface{}) (int, error) {
 	var count int
@@ -86,6 +111,39 @@ func ExistTagByID(id int) (bool, error) {
 	return false, nil
 }
 
+// GetTagsByIDRange gets the tags matching the constraints with minID < id <= maxID
+func GetTagsByIDRange(minID, maxID int, maps interface{}) ([]Tag, error) {
+	var tags []Tag
+	err := db.Where(maps).Where("id > ? AND id <= ?", minID, maxID).Order("id").Find(&tags).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// GetMaxTagID gets the highest tag ID, 0 when there are no tags
+func GetMaxTagID() (int, error) {
+	var tag Tag
+	err := db.Select("id").Order("id DESC").First(&tag).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+
+	return tag.ID, nil
+}
+
+// GetTag Get a single tag based on ID
+func GetTag(id int) (*Tag, error) {
+	var tag Tag
+	err := db.Where("id = ? AND deleted_on = ? ", id, 0).First(&tag).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
 // DeleteTag delete a tag
 func DeleteTag(id int) error {
 	if err := db.Where("id = ?", id).Delete(&Tag{}).Error; err != nil {
@@ -104,6 +162,34 @@ func EditTag(id int, data interface{}) error {
 	return nil
 }
 
+// SaveTags creates the tags without an ID and updates the others in one transaction.
+// A negative State leaves the state of an updated tag unchanged
+func SaveTags(tags []*Tag) error {
+	tx := db.Begin()
+	for _, tag := range tags {
+		var err error
+		if tag.ID > 0 {
+			data := map[string]interface{}{
+				"name":        tag.Name,
+				"modified_by": tag.ModifiedBy,
+			}
+			if tag.State >= 0 {
+				data["state"] = tag.State
+			}
+			err = tx.Model(&Tag{}).Where("id = ? AND deleted_on = ? ", tag.ID, 0).Updates(data).Error
+		} else {
+			err = tx.Create(tag).Error
+		}
+
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
 // CleanAllTag clear all tag
 func CleanAllTag() (bool, error) {
 	if err := db.Unscoped().Where("deleted_on != ? ", 0).Delete(&Tag{}).Error; err != nil {
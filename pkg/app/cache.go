@@ -0,0 +1,33 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CheckNotModified sets the validators of a cacheable response and answers 304
+// when the client copy is still fresh, in which case nothing else should be written
+func (g *Gin) CheckNotModified(etag string, lastModified time.Time) bool {
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	g.C.Header("ETag", etag)
+	g.C.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	if match := g.C.GetHeader("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == etag || candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				g.C.Status(http.StatusNotModified)
+				return true
+			}
+		}
+		return false
+	}
+
+	if since, err := http.ParseTime(g.C.GetHeader("If-Modified-Since")); err == nil && !lastModified.After(since) {
+		g.C.Status(http.StatusNotModified)
+		return true
+	}
+
+	return false
+}
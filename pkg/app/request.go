@@ -1,7 +1,11 @@
 package app
 
 import (
+	"errors"
+	"io"
+
 	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
 
 	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 )
@@ -14,3 +18,56 @@ func MarkErrors(errors []*validation.Error) {
 
 	return
 }
+
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// BodyLimit is a request body cut at a number of bytes
+type BodyLimit struct {
+	body      io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+// LimitBody caps the request body at max bytes, so that whatever decodes it
+// reads no more. Reads past the cap fail with ErrBodyTooLarge
+func LimitBody(c *gin.Context, max int64) *BodyLimit {
+	limit := &BodyLimit{
+		body:      c.Request.Body,
+		remaining: max,
+		exceeded:  c.Request.ContentLength > max,
+	}
+	c.Request.Body = limit
+
+	return limit
+}
+
+// Exceeded reports whether the body is larger than the cap, as its
+// Content-Length announced or as found while reading it
+func (l *BodyLimit) Exceeded() bool {
+	return l.exceeded
+}
+
+func (l *BodyLimit) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, ErrBodyTooLarge
+	}
+
+	// one byte more than allowed tells a body of exactly max bytes from a larger one
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.body.Read(p)
+	if int64(n) > l.remaining {
+		n = int(l.remaining)
+		l.remaining = 0
+		l.exceeded = true
+		return n, ErrBodyTooLarge
+	}
+
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func (l *BodyLimit) Close() error {
+	return l.body.Close()
+}
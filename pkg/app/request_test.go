@@ -0,0 +1,50 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLimitBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		max           int64
+		read          string
+		exceeded      bool
+	}{
+		{name: "under the cap", body: "abc", contentLength: 3, max: 5, read: "abc"},
+		{name: "at the cap", body: "abcde", contentLength: 5, max: 5, read: "abcde"},
+		{name: "over the cap", body: "abcdef", contentLength: -1, max: 5, read: "abcde", exceeded: true},
+		{name: "announced over the cap", body: "abcdef", contentLength: 6, max: 5, read: "", exceeded: true},
+		{name: "empty", body: "", contentLength: 0, max: 0, read: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			c.Request.ContentLength = tt.contentLength
+
+			limit := LimitBody(c, tt.max)
+			data, err := ioutil.ReadAll(c.Request.Body)
+			if string(data) != tt.read {
+				t.Errorf("read %q, want %q", data, tt.read)
+			}
+			if tt.exceeded && err != ErrBodyTooLarge {
+				t.Errorf("err = %v, want ErrBodyTooLarge", err)
+			}
+			if !tt.exceeded && err != nil {
+				t.Errorf("err = %v, want nil", err)
+			}
+			if limit.Exceeded() != tt.exceeded {
+				t.Errorf("Exceeded() = %v, want %v", limit.Exceeded(), tt.exceeded)
+			}
+		})
+	}
+}
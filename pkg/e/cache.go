@@ -3,4 +3,19 @@ package e
 const (
 	CACHE_ARTICLE = "ARTICLE"
 	CACHE_TAG     = "TAG"
+
+	CACHE_ARTICLE_REACTION = "ARTICLE_REACTION"
+	CACHE_ARTICLE_RELATED  = "ARTICLE_RELATED"
+	CACHE_ARTICLE_VIEWS    = "ARTICLE_VIEWS"
+	CACHE_ARTICLE_COVIEW   = "ARTICLE_COVIEW"
+
+	CACHE_FEED    = "FEED"
+	CACHE_SITEMAP = "SITEMAP"
+
+	CACHE_EXPORT_JOB = "EXPORT_JOB"
+
+	CACHE_UPLOAD_RATE     = "UPLOAD_RATE"
+	CACHE_UPLOAD_RESERVED = "UPLOAD_RESERVED"
+
+	CACHE_WEBDAV_AUTH_FAILURE = "WEBDAV_AUTH_FAILURE"
 )
@@ -26,12 +26,83 @@ const (
 	ERROR_GET_ARTICLE_FAIL         = 10018
 	ERROR_GEN_ARTICLE_POSTER_FAIL  = 10019
 
+	ERROR_ADD_ARTICLE_REACTION_FAIL    = 10020
+	ERROR_DELETE_ARTICLE_REACTION_FAIL = 10021
+	ERROR_GET_ARTICLE_REACTIONS_FAIL   = 10022
+	ERROR_GET_RELATED_ARTICLES_FAIL    = 10023
+
+	ERROR_NOT_EXIST_SERIES           = 10024
+	ERROR_CHECK_EXIST_SERIES_FAIL    = 10025
+	ERROR_GET_SERIES_FAIL            = 10026
+	ERROR_COUNT_SERIES_FAIL          = 10027
+	ERROR_ADD_SERIES_FAIL            = 10028
+	ERROR_EDIT_SERIES_FAIL           = 10029
+	ERROR_DELETE_SERIES_FAIL         = 10030
+	ERROR_ADD_SERIES_ARTICLE_FAIL    = 10031
+	ERROR_DELETE_SERIES_ARTICLE_FAIL = 10032
+	ERROR_SORT_SERIES_ARTICLES_FAIL  = 10033
+	ERROR_EXIST_SERIES_ARTICLE       = 10034
+	ERROR_SERIES_ARTICLES_MISMATCH   = 10035
+
+	ERROR_IMPORT_ARTICLE_FAIL      = 10036
+	ERROR_IMPORT_ARTICLE_FORMAT    = 10037
+	ERROR_IMPORT_ARTICLE_TOO_LARGE = 10038
+	ERROR_EXPORT_ARTICLE_FAIL      = 10039
+
+	ERROR_IMPORT_TAG_FORMAT       = 10040
+	ERROR_IMPORT_TAG_INVALID_ROWS = 10041
+	ERROR_IMPORT_TAG_TOO_LARGE    = 10042
+
 	ERROR_AUTH_CHECK_TOKEN_FAIL    = 20001
 	ERROR_AUTH_CHECK_TOKEN_TIMEOUT = 20002
 	ERROR_AUTH_TOKEN               = 20003
 	ERROR_AUTH                     = 20004
+	ERROR_AUTH_FORBIDDEN           = 20005
+	ERROR_AUTH_TOO_MANY_FAILURES   = 20006
+	ERROR_GET_API_KEYS_FAIL        = 20007
+	ERROR_ADD_API_KEY_FAIL         = 20008
+	ERROR_NOT_EXIST_API_KEY        = 20009
+	ERROR_CHECK_EXIST_API_KEY_FAIL = 20010
+	ERROR_DELETE_API_KEY_FAIL      = 20011
+
+	ERROR_UPLOAD_SAVE_IMAGE_FAIL      = 30001
+	ERROR_UPLOAD_CHECK_IMAGE_FAIL     = 30002
+	ERROR_UPLOAD_CHECK_IMAGE_FORMAT   = 30003
+	ERROR_UPLOAD_IMAGE_TOO_LARGE      = 30004
+	ERROR_UPLOAD_IMAGE_DIMENSIONS     = 30005
+	ERROR_NOT_EXIST_MEDIA             = 30006
+	ERROR_CHECK_EXIST_MEDIA_FAIL      = 30007
+	ERROR_GET_MEDIA_FAIL              = 30008
+	ERROR_COUNT_MEDIA_FAIL            = 30009
+	ERROR_EDIT_MEDIA_FAIL             = 30010
+	ERROR_DELETE_MEDIA_FAIL           = 30011
+	ERROR_MEDIA_IN_USE                = 30012
+	ERROR_GC_UPLOADS_FAIL             = 30013
+	ERROR_GC_RESTORE_FAIL             = 30014
+	ERROR_NOT_EXIST_QUARANTINE_FILE   = 30015
+	ERROR_NOT_EXIST_UPLOAD            = 30016
+	ERROR_UPLOAD_FILE_TYPE            = 30017
+	ERROR_UPLOAD_FILE_TOO_LARGE       = 30018
+	ERROR_UPLOAD_SAVE_FILE_FAIL       = 30019
+	ERROR_NOT_EXIST_ATTACHMENT        = 30020
+	ERROR_CHECK_EXIST_ATTACHMENT_FAIL = 30021
+	ERROR_GET_ATTACHMENTS_FAIL        = 30022
+	ERROR_DELETE_ATTACHMENT_FAIL      = 30023
+	ERROR_UPLOAD_QUOTA_EXCEEDED       = 30024
+	ERROR_UPLOAD_RATE_LIMIT           = 30025
+	ERROR_GET_UPLOAD_USAGE_FAIL       = 30026
+
+	ERROR_GET_FEED_FAIL    = 40001
+	ERROR_GET_SITEMAP_FAIL = 40002
 
-	ERROR_UPLOAD_SAVE_IMAGE_FAIL    = 30001
-	ERROR_UPLOAD_CHECK_IMAGE_FAIL   = 30002
-	ERROR_UPLOAD_CHECK_IMAGE_FORMAT = 30003
+	ERROR_EXPORT_QUEUE_FULL       = 50001
+	ERROR_ENQUEUE_EXPORT_FAIL     = 50002
+	ERROR_NOT_EXIST_EXPORT_JOB    = 50003
+	ERROR_GET_EXPORT_JOB_FAIL     = 50004
+	ERROR_EXPORT_DOWNLOAD_EXPIRED = 50005
+	ERROR_NOT_EXIST_EXPORT_FILE   = 50006
+	ERROR_BACKUP_INVALID_ARCHIVE  = 50007
+	ERROR_BACKUP_VERSION          = 50008
+	ERROR_RESTORE_NOT_EMPTY       = 50009
+	ERROR_RESTORE_BACKUP_FAIL     = 50010
 )
@@ -1,35 +1,100 @@
 package e
 
 var MsgFlags = map[int]string{
-	SUCCESS:                         "ok",
-	ERROR:                           "fail",
-	INVALID_PARAMS:                  "请求参数错误",
-	ERROR_EXIST_TAG:                 "已存在该标签名称",
-	ERROR_EXIST_TAG_FAIL:            "获取已存在标签失败",
-	ERROR_NOT_EXIST_TAG:             "该标签不存在",
-	ERROR_GET_TAGS_FAIL:             "获取所有标签失败",
-	ERROR_COUNT_TAG_FAIL:            "统计标签失败",
-	ERROR_ADD_TAG_FAIL:              "新增标签失败",
-	ERROR_EDIT_TAG_FAIL:             "修改标签失败",
-	ERROR_DELETE_TAG_FAIL:           "删除标签失败",
-	ERROR_EXPORT_TAG_FAIL:           "导出标签失败",
-	ERROR_IMPORT_TAG_FAIL:           "导入标签失败",
-	ERROR_NOT_EXIST_ARTICLE:         "该文章不存在",
-	ERROR_ADD_ARTICLE_FAIL:          "新增文章失败",
-	ERROR_DELETE_ARTICLE_FAIL:       "删除文章失败",
-	ERROR_CHECK_EXIST_ARTICLE_FAIL:  "检查文章是否存在失败",
-	ERROR_EDIT_ARTICLE_FAIL:         "修改文章失败",
-	ERROR_COUNT_ARTICLE_FAIL:        "统计文章失败",
-	ERROR_GET_ARTICLES_FAIL:         "获取多个文章失败",
-	ERROR_GET_ARTICLE_FAIL:          "获取单个文章失败",
-	ERROR_GEN_ARTICLE_POSTER_FAIL:   "生成文章海报失败",
-	ERROR_AUTH_CHECK_TOKEN_FAIL:     "Token鉴权失败",
-	ERROR_AUTH_CHECK_TOKEN_TIMEOUT:  "Token已超时",
-	ERROR_AUTH_TOKEN:                "Token生成失败",
-	ERROR_AUTH:                      "Token错误",
-	ERROR_UPLOAD_SAVE_IMAGE_FAIL:    "保存图片失败",
-	ERROR_UPLOAD_CHECK_IMAGE_FAIL:   "检查图片失败",
-	ERROR_UPLOAD_CHECK_IMAGE_FORMAT: "校验图片错误，图片格式或大小有问题",
+	SUCCESS:                            "ok",
+	ERROR:                              "fail",
+	INVALID_PARAMS:                     "请求参数错误",
+	ERROR_EXIST_TAG:                    "已存在该标签名称",
+	ERROR_EXIST_TAG_FAIL:               "获取已存在标签失败",
+	ERROR_NOT_EXIST_TAG:                "该标签不存在",
+	ERROR_GET_TAGS_FAIL:                "获取所有标签失败",
+	ERROR_COUNT_TAG_FAIL:               "统计标签失败",
+	ERROR_ADD_TAG_FAIL:                 "新增标签失败",
+	ERROR_EDIT_TAG_FAIL:                "修改标签失败",
+	ERROR_DELETE_TAG_FAIL:              "删除标签失败",
+	ERROR_EXPORT_TAG_FAIL:              "导出标签失败",
+	ERROR_IMPORT_TAG_FAIL:              "导入标签失败",
+	ERROR_NOT_EXIST_ARTICLE:            "该文章不存在",
+	ERROR_ADD_ARTICLE_FAIL:             "新增文章失败",
+	ERROR_DELETE_ARTICLE_FAIL:          "删除文章失败",
+	ERROR_CHECK_EXIST_ARTICLE_FAIL:     "检查文章是否存在失败",
+	ERROR_EDIT_ARTICLE_FAIL:            "修改文章失败",
+	ERROR_COUNT_ARTICLE_FAIL:           "统计文章失败",
+	ERROR_GET_ARTICLES_FAIL:            "获取多个文章失败",
+	ERROR_GET_ARTICLE_FAIL:             "获取单个文章失败",
+	ERROR_GEN_ARTICLE_POSTER_FAIL:      "生成文章海报失败",
+	ERROR_ADD_ARTICLE_REACTION_FAIL:    "新增文章表态失败",
+	ERROR_DELETE_ARTICLE_REACTION_FAIL: "取消文章表态失败",
+	ERROR_GET_ARTICLE_REACTIONS_FAIL:   "获取文章表态失败",
+	ERROR_GET_RELATED_ARTICLES_FAIL:    "获取相关文章失败",
+	ERROR_NOT_EXIST_SERIES:             "该系列不存在",
+	ERROR_CHECK_EXIST_SERIES_FAIL:      "检查系列是否存在失败",
+	ERROR_GET_SERIES_FAIL:              "获取系列失败",
+	ERROR_COUNT_SERIES_FAIL:            "统计系列失败",
+	ERROR_ADD_SERIES_FAIL:              "新增系列失败",
+	ERROR_EDIT_SERIES_FAIL:             "修改系列失败",
+	ERROR_DELETE_SERIES_FAIL:           "删除系列失败",
+	ERROR_ADD_SERIES_ARTICLE_FAIL:      "加入系列文章失败",
+	ERROR_DELETE_SERIES_ARTICLE_FAIL:   "移出系列文章失败",
+	ERROR_SORT_SERIES_ARTICLES_FAIL:    "调整系列文章顺序失败",
+	ERROR_EXIST_SERIES_ARTICLE:         "该文章已属于其他系列",
+	ERROR_SERIES_ARTICLES_MISMATCH:     "排序必须包含系列内的全部文章",
+	ERROR_IMPORT_ARTICLE_FAIL:          "导入文章失败",
+	ERROR_IMPORT_ARTICLE_FORMAT:        "导入文件不是有效的zip压缩包",
+	ERROR_IMPORT_ARTICLE_TOO_LARGE:     "导入文件过大",
+	ERROR_EXPORT_ARTICLE_FAIL:          "导出文章失败",
+	ERROR_IMPORT_TAG_FORMAT:            "标签文件无法读取或缺少名称列",
+	ERROR_IMPORT_TAG_INVALID_ROWS:      "标签数据校验失败，未导入任何标签",
+	ERROR_IMPORT_TAG_TOO_LARGE:         "标签文件过大",
+	ERROR_AUTH_CHECK_TOKEN_FAIL:        "Token鉴权失败",
+	ERROR_AUTH_CHECK_TOKEN_TIMEOUT:     "Token已超时",
+	ERROR_AUTH_TOKEN:                   "Token生成失败",
+	ERROR_AUTH:                         "Token错误",
+	ERROR_AUTH_FORBIDDEN:               "没有权限",
+	ERROR_AUTH_TOO_MANY_FAILURES:       "登录失败次数过多，请稍后再试",
+	ERROR_GET_API_KEYS_FAIL:            "获取API密钥失败",
+	ERROR_ADD_API_KEY_FAIL:             "创建API密钥失败",
+	ERROR_NOT_EXIST_API_KEY:            "该API密钥不存在",
+	ERROR_CHECK_EXIST_API_KEY_FAIL:     "检查API密钥是否存在失败",
+	ERROR_DELETE_API_KEY_FAIL:          "删除API密钥失败",
+	ERROR_UPLOAD_SAVE_IMAGE_FAIL:       "保存图片失败",
+	ERROR_UPLOAD_CHECK_IMAGE_FAIL:      "检查图片失败",
+	ERROR_UPLOAD_CHECK_IMAGE_FORMAT:    "校验图片错误，图片格式或大小有问题",
+	ERROR_UPLOAD_IMAGE_TOO_LARGE:       "图片文件过大",
+	ERROR_UPLOAD_IMAGE_DIMENSIONS:      "图片尺寸过大",
+	ERROR_NOT_EXIST_MEDIA:              "该媒体文件不存在",
+	ERROR_CHECK_EXIST_MEDIA_FAIL:       "检查媒体文件是否存在失败",
+	ERROR_GET_MEDIA_FAIL:               "获取媒体文件失败",
+	ERROR_COUNT_MEDIA_FAIL:             "统计媒体文件失败",
+	ERROR_EDIT_MEDIA_FAIL:              "修改媒体文件失败",
+	ERROR_DELETE_MEDIA_FAIL:            "删除媒体文件失败",
+	ERROR_MEDIA_IN_USE:                 "媒体文件正被文章使用，无法删除",
+	ERROR_GC_UPLOADS_FAIL:              "清理未使用的上传文件失败",
+	ERROR_GC_RESTORE_FAIL:              "恢复隔离文件失败",
+	ERROR_NOT_EXIST_QUARANTINE_FILE:    "隔离区中不存在该文件",
+	ERROR_NOT_EXIST_UPLOAD:             "上传不存在或已过期",
+	ERROR_UPLOAD_FILE_TYPE:             "不支持的附件类型",
+	ERROR_UPLOAD_FILE_TOO_LARGE:        "附件超过该类型允许的大小",
+	ERROR_UPLOAD_SAVE_FILE_FAIL:        "保存附件失败",
+	ERROR_NOT_EXIST_ATTACHMENT:         "该附件不存在",
+	ERROR_CHECK_EXIST_ATTACHMENT_FAIL:  "检查附件是否存在失败",
+	ERROR_GET_ATTACHMENTS_FAIL:         "获取附件失败",
+	ERROR_DELETE_ATTACHMENT_FAIL:       "删除附件失败",
+	ERROR_UPLOAD_QUOTA_EXCEEDED:        "上传空间已用完",
+	ERROR_UPLOAD_RATE_LIMIT:            "上传过于频繁，请稍后再试",
+	ERROR_GET_UPLOAD_USAGE_FAIL:        "获取上传空间用量失败",
+	ERROR_GET_FEED_FAIL:                "获取订阅源失败",
+	ERROR_GET_SITEMAP_FAIL:             "获取站点地图失败",
+	ERROR_EXPORT_QUEUE_FULL:            "导出任务过多，请稍后再试",
+	ERROR_ENQUEUE_EXPORT_FAIL:          "创建导出任务失败",
+	ERROR_NOT_EXIST_EXPORT_JOB:         "该导出任务不存在或已过期",
+	ERROR_GET_EXPORT_JOB_FAIL:          "获取导出任务失败",
+	ERROR_EXPORT_DOWNLOAD_EXPIRED:      "下载链接无效或已过期",
+	ERROR_NOT_EXIST_EXPORT_FILE:        "导出文件不存在",
+	ERROR_BACKUP_INVALID_ARCHIVE:       "备份文件无效或已损坏",
+	ERROR_BACKUP_VERSION:               "备份文件版本过新，无法恢复",
+	ERROR_RESTORE_NOT_EMPTY:            "数据库已有数据，如需覆盖请设置overwrite",
+	ERROR_RESTORE_BACKUP_FAIL:          "恢复备份失败",
 }
 
 // GetMsg get error information based on Code
@@ -1,12 +1,38 @@
 package export
 
-import "github.com/EDDYCJY/go-gin-example/pkg/setting"
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
 
-const EXT = ".xlsx"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
 
-// GetExcelFullUrl get the full access path of the Excel file
+const (
+	EXT       = ".xlsx"
+	ZIP_EXT   = ".zip"
+	JSONL_EXT = ".jsonl"
+	CSV_EXT   = ".csv"
+	JSON_EXT  = ".json"
+)
+
+var contentTypes = map[string]string{
+	EXT:       "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	ZIP_EXT:   "application/zip",
+	JSONL_EXT: "application/x-ndjson",
+	CSV_EXT:   "text/csv; charset=utf-8",
+	JSON_EXT:  "application/json; charset=utf-8",
+}
+
+// ProgressFunc is told how many of the total rows an export has written so far
+type ProgressFunc func(done, total int)
+
+// GetExcelFullUrl get the signed download URL of the Excel file, valid for the configured time
 func GetExcelFullUrl(name string) string {
-	return setting.AppSetting.PrefixUrl + "/" + GetExcelPath() + name
+	return GetSignedUrl(name, time.Now().Add(setting.ExportSetting.UrlExpire))
 }
 
 // GetExcelPath get the relative save path of the Excel file
@@ -14,7 +40,51 @@ func GetExcelPath() string {
 	return setting.AppSetting.ExportSavePath
 }
 
-// GetExcelFullPath Get the full save path of the Excel file
-func GetExcelFullPath() string {
-	return setting.AppSetting.RuntimeRootPath + GetExcelPath()
+// Save writes an export file with write and stores it under the name. The file
+// is built in a temporary file first, as remote storage needs its size up front
+func Save(name string, write func(w io.Writer) error) error {
+	f, err := ioutil.TempFile("", "export-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := write(f); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return storage.Exports.Put(name, f)
+}
+
+// GetFileName get a new export file name with the prefix and extension. The
+// id of the job writing the file keeps exports started in the same second apart
+func GetFileName(prefix, id, ext string) string {
+	name := prefix + "-" + strconv.Itoa(int(time.Now().Unix()))
+	if id != "" {
+		name += "-" + id
+	}
+
+	return name + ext
+}
+
+// GetContentType get the media type of an export file by its extension
+func GetContentType(ext string) string {
+	if contentType, ok := contentTypes[ext]; ok {
+		return contentType
+	}
+
+	return "application/octet-stream"
+}
+
+// GetBatchSize get how many rows an export reads from the database at a time
+func GetBatchSize() int {
+	if setting.ExportSetting.BatchSize > 0 {
+		return setting.ExportSetting.BatchSize
+	}
+
+	return 500
 }
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// GetSignedUrl get a download URL of an export file that stops working at expires
+func GetSignedUrl(name string, expires time.Time) string {
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{
+		"expires":   {unix},
+		"signature": {sign(name, unix)},
+	}
+
+	return setting.AppSetting.PrefixUrl + "/" + GetExcelPath() + url.PathEscape(name) + "?" + query.Encode()
+}
+
+// CheckSignature checks that a download URL was signed for the file and has not expired
+func CheckSignature(name, expires, signature string) bool {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+
+	expected, err := hex.DecodeString(sign(name, expires))
+	if err != nil {
+		return false
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(expected, actual)
+}
+
+func sign(name, expires string) string {
+	mac := hmac.New(sha256.New, []byte(setting.AppSetting.JwtSecret))
+	mac.Write([]byte(name + "\n" + expires))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
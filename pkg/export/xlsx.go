@@ -0,0 +1,134 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+)
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+// xlsxParts are the fixed parts of a workbook with one sheet, in the order they are zipped
+var xlsxParts = []struct {
+	Name    string
+	Content string
+}{
+	{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="1"><fill><patternFill patternType="none"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// XlsxWriter writes a workbook with a single sheet row by row, so that only the
+// current row is held in memory. Every cell is written as an inline string
+type XlsxWriter struct {
+	zw     *zip.Writer
+	w      *bufio.Writer
+	rows   int
+	err    error
+	closed bool
+}
+
+// NewXlsxWriter starts a workbook on w with one sheet of the given name
+func NewXlsxWriter(w io.Writer, sheet string) (*XlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		if err := writePart(zw, part.Name, part.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	workbook := `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escape(sheet) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	if err := writePart(zw, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	fw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	x := &XlsxWriter{zw: zw, w: bufio.NewWriter(fw)}
+	x.w.WriteString(xmlHeader)
+	x.w.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	return x, nil
+}
+
+// WriteRow appends a row to the sheet
+func (x *XlsxWriter) WriteRow(cells []string) error {
+	if x.err != nil {
+		return x.err
+	}
+
+	x.rows++
+	r := strconv.Itoa(x.rows)
+	x.w.WriteString(`<row r="` + r + `">`)
+	for i, cell := range cells {
+		x.w.WriteString(`<c r="` + excelize.ToAlphaString(i) + r + `" t="inlineStr"><is><t xml:space="preserve">`)
+		x.w.WriteString(escape(cell))
+		x.w.WriteString(`</t></is></c>`)
+	}
+	_, x.err = x.w.WriteString(`</row>`)
+
+	return x.err
+}
+
+// Close finishes the sheet and the workbook, without closing the underlying writer
+func (x *XlsxWriter) Close() error {
+	if x.closed {
+		return x.err
+	}
+	x.closed = true
+
+	if x.err == nil {
+		x.w.WriteString(`</sheetData></worksheet>`)
+		x.err = x.w.Flush()
+	}
+	if err := x.zw.Close(); x.err == nil {
+		x.err = err
+	}
+
+	return x.err
+}
+
+func writePart(zw *zip.Writer, name, content string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, xmlHeader+content)
+	return err
+}
+
+// escape escapes text for XML, replacing the characters XML cannot hold
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -2,17 +2,23 @@ package file
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io"
 	"mime/multipart"
 	"os"
 	"path"
 )
 
-// GetSize get the file size
+// GetSize get the file size without reading it, leaving the read offset at the start
 func GetSize(f multipart.File) (int, error) {
-	content, err := ioutil.ReadAll(f)
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
 
-	return len(content), err
+	return int(size), nil
 }
 
 // GetExt get the file ext
@@ -0,0 +1,183 @@
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	YAML_DELIMITER = "---"
+	TOML_DELIMITER = "+++"
+)
+
+var ErrUnclosed = errors.New("front matter is not closed")
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Matter holds the front matter fields of a document
+type Matter map[string]interface{}
+
+// Parse splits a document into its front matter and body. YAML front matter is
+// fenced by "---" and TOML by "+++"; a document without either has an empty Matter
+func Parse(data []byte) (Matter, []byte, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
+
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	delimiter := strings.TrimSpace(string(firstLine))
+	if delimiter != YAML_DELIMITER && delimiter != TOML_DELIMITER {
+		return Matter{}, data, nil
+	}
+
+	rest := data[len(firstLine):]
+	end := bytes.Index(rest, []byte("\n"+delimiter))
+	if end < 0 {
+		return nil, nil, ErrUnclosed
+	}
+
+	head := rest[:end]
+	body := rest[end+1+len(delimiter):]
+	if i := bytes.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = nil
+	}
+
+	matter := Matter{}
+	if delimiter == YAML_DELIMITER {
+		if err := yaml.Unmarshal(head, &matter); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		var err error
+		if matter, err = parseTOML(head); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return matter, body, nil
+}
+
+// Marshal writes v as YAML front matter followed by body
+func Marshal(v interface{}, body []byte) ([]byte, error) {
+	head, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(YAML_DELIMITER + "\n")
+	buf.Write(head)
+	buf.WriteString(YAML_DELIMITER + "\n\n")
+	buf.Write(body)
+
+	return buf.Bytes(), nil
+}
+
+// String returns the first of keys holding a scalar value
+func (m Matter) String(keys ...string) string {
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case nil:
+			continue
+		case string:
+			if v != "" {
+				return v
+			}
+		case []interface{}:
+			if len(v) > 0 {
+				return fmt.Sprint(v[0])
+			}
+		case time.Time:
+			return v.Format(time.RFC3339)
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+
+	return ""
+}
+
+// Strings collects the values of keys holding either a list or a single value
+func (m Matter) Strings(keys ...string) []string {
+	var values []string
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case nil:
+		case []interface{}:
+			for _, item := range v {
+				if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+					values = append(values, s)
+				}
+			}
+		default:
+			if s := strings.TrimSpace(fmt.Sprint(v)); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+
+	return values
+}
+
+// Bool reports whether key is set to true
+func (m Matter) Bool(key string) bool {
+	switch v := m[key].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+
+	return false
+}
+
+// Time returns the first of keys holding a parsable date
+func (m Matter) Time(keys ...string) (time.Time, bool) {
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case time.Time:
+			return v, true
+		case string:
+			for _, layout := range timeLayouts {
+				if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+					return t, true
+				}
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Map returns the nested table under key, as both YAML and TOML can nest them
+func (m Matter) Map(key string) Matter {
+	nested := Matter{}
+	switch v := m[key].(type) {
+	case map[interface{}]interface{}:
+		for k, value := range v {
+			nested[fmt.Sprint(k)] = value
+		}
+	case Matter:
+		return v
+	}
+
+	return nested
+}
@@ -0,0 +1,128 @@
+package frontmatter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		title  string
+		tags   []string
+		draft  bool
+		date   string
+		cover  string
+		body   string
+		hasErr bool
+	}{
+		{
+			name: "no front matter",
+			data: "# Hello\n",
+			body: "# Hello\n",
+		},
+		{
+			name:  "yaml",
+			data:  "---\ntitle: Hello\ntags: [go, gin]\ndraft: true\ndate: 2019-05-01\n---\nBody\n",
+			title: "Hello",
+			tags:  []string{"go", "gin"},
+			draft: true,
+			date:  "2019-05-01",
+			body:  "Body\n",
+		},
+		{
+			name:  "yaml with byte order mark and CRLF",
+			data:  "\xef\xbb\xbf---\r\ntitle: Hello\r\n---\r\nBody\r\n",
+			title: "Hello",
+			body:  "Body\n",
+		},
+		{
+			name:  "toml",
+			data:  "+++\ntitle = \"Hello\" # comment\ntags = [\"go\", \"gin\"]\ndraft = false\ndate = 2019-05-01T10:00:00Z\n+++\nBody\n",
+			title: "Hello",
+			tags:  []string{"go", "gin"},
+			date:  "2019-05-01",
+			body:  "Body\n",
+		},
+		{
+			name:  "toml multi-line array and string",
+			data:  "+++\ntitle = \"\"\"Hello\nWorld\"\"\"\ntags = [\n  \"go\",\n  \"gin\",\n]\n+++\n",
+			title: "Hello\nWorld",
+			tags:  []string{"go", "gin"},
+		},
+		{
+			name:  "toml tables and arrays of tables",
+			data:  "+++\ntitle = 'Hello'\n[cover]\nimage = \"cover.png\"\n[[resources]]\nsrc = \"a.png\"\n[[resources]]\nsrc = \"b.png\"\n+++\n",
+			title: "Hello",
+			cover: "cover.png",
+		},
+		{
+			name:   "unclosed",
+			data:   "---\ntitle: Hello\n",
+			hasErr: true,
+		},
+		{
+			name:   "invalid toml",
+			data:   "+++\ntitle = \n+++\n",
+			hasErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matter, body, err := Parse([]byte(tt.data))
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("Parse() err = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() err = %v", err)
+			}
+
+			if got := matter.String("title"); got != tt.title {
+				t.Errorf("title = %q, want %q", got, tt.title)
+			}
+			if got := matter.Strings("tags"); !reflect.DeepEqual(got, tt.tags) {
+				t.Errorf("tags = %q, want %q", got, tt.tags)
+			}
+			if got := matter.Bool("draft"); got != tt.draft {
+				t.Errorf("draft = %v, want %v", got, tt.draft)
+			}
+			if date, ok := matter.Time("date"); tt.date != "" && (!ok || date.Format("2006-01-02") != tt.date) {
+				t.Errorf("date = %v, want %s", date, tt.date)
+			}
+			if got := matter.Map("cover").String("image"); got != tt.cover {
+				t.Errorf("cover = %q, want %q", got, tt.cover)
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestMatterTime(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+		ok    bool
+	}{
+		{"2019-05-01", "2019-05-01 00:00", true},
+		{"2019-05-01 10:30", "2019-05-01 10:30", true},
+		{"2019-05-01T10:30:00", "2019-05-01 10:30", true},
+		{time.Date(2019, 5, 1, 10, 30, 0, 0, time.Local), "2019-05-01 10:30", true},
+		{"yesterday", "", false},
+		{nil, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Matter{"date": tt.value}.Time("date")
+		if ok != tt.ok || (ok && got.Format("2006-01-02 15:04") != tt.want) {
+			t.Errorf("Time(%v) = %v, %v, want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package frontmatter
+
+import (
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML decodes TOML front matter, with its tables as nested Matter
+func parseTOML(data []byte) (Matter, error) {
+	var raw map[string]interface{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, err
+	}
+
+	return normalizeTOML(raw).(Matter), nil
+}
+
+// normalizeTOML converts decoded TOML to the types YAML front matter is read
+// as: tables become Matter, arrays []interface{} and integers int
+func normalizeTOML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		matter := make(Matter, len(v))
+		for key, value := range v {
+			matter[key] = normalizeTOML(value)
+		}
+		return matter
+	case []map[string]interface{}:
+		items := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			items = append(items, normalizeTOML(item))
+		}
+		return items
+	case []interface{}:
+		items := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			items = append(items, normalizeTOML(item))
+		}
+		return items
+	case int64:
+		return int(v)
+	}
+
+	return v
+}
@@ -96,6 +96,14 @@ func Delete(key string) (bool, error) {
 	return redis.Bool(conn.Do("DEL", key))
 }
 
+// Keys returns the keys matching a pattern
+func Keys(pattern string) ([]string, error) {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	return redis.Strings(conn.Do("KEYS", pattern))
+}
+
 // LikeDeletes batch delete
 func LikeDeletes(key string) error {
 	conn := RedisConn.Get()
@@ -115,3 +123,136 @@ func LikeDeletes(key string) error {
 
 	return nil
 }
+
+// IncrBy increments a counter and refreshes the key expiry, returning the new value
+func IncrBy(key string, increment, time int) (int, error) {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	conn.Send("INCRBY", key, increment)
+	conn.Send("EXPIRE", key, time)
+	values, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return 0, err
+	}
+
+	return redis.Int(values[0], nil)
+}
+
+// HIncrBy atomically increments a hash field and returns the new value
+func HIncrBy(key, field string, increment int) (int, error) {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	return redis.Int(conn.Do("HINCRBY", key, field, increment))
+}
+
+// HGetAllInt get all fields of a hash whose values are integers
+func HGetAllInt(key string) (map[string]int, error) {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	return redis.IntMap(conn.Do("HGETALL", key))
+}
+
+// HSetAll replaces a hash with the given fields and sets its expiry
+func HSetAll(key string, fields map[string]int, time int) error {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	conn.Send("DEL", key)
+	if len(fields) > 0 {
+		conn.Send("HMSET", redis.Args{}.Add(key).AddFlat(fields)...)
+	}
+	conn.Send("EXPIRE", key, time)
+	_, err := conn.Do("EXEC")
+
+	return err
+}
+
+// SAdd add members to a set
+func SAdd(key string, members ...interface{}) error {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SADD", redis.Args{}.Add(key).Add(members...)...)
+	return err
+}
+
+// SPopAll atomically removes and returns all members of a set
+func SPopAll(key string) ([]string, error) {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	conn.Send("SMEMBERS", key)
+	conn.Send("DEL", key)
+	replies, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return nil, err
+	}
+
+	return redis.Strings(replies[0], nil)
+}
+
+// SMembers returns all members of a set
+func SMembers(key string) ([]string, error) {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	return redis.Strings(conn.Do("SMEMBERS", key))
+}
+
+// SRem removes members from a set
+func SRem(key string, members ...interface{}) error {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SREM", redis.Args{}.Add(key).Add(members...)...)
+	return err
+}
+
+// LPushTrim pushes a value to the head of a list and keeps only the newest max entries
+func LPushTrim(key string, value interface{}, max, time int) error {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	conn.Send("LPUSH", key, value)
+	conn.Send("LTRIM", key, 0, max-1)
+	conn.Send("EXPIRE", key, time)
+	_, err := conn.Do("EXEC")
+
+	return err
+}
+
+// LRange get a range of list elements
+func LRange(key string, start, stop int) ([]string, error) {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	return redis.Strings(conn.Do("LRANGE", key, start, stop))
+}
+
+// ZIncrBy increments the score of a sorted set member and refreshes the key expiry
+func ZIncrBy(key string, member interface{}, increment, time int) error {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	conn.Send("ZINCRBY", key, increment, member)
+	conn.Send("EXPIRE", key, time)
+	_, err := conn.Do("EXEC")
+
+	return err
+}
+
+// ZRevRangeWithScores get the members with the highest integer scores
+func ZRevRangeWithScores(key string, start, stop int) (map[string]int, error) {
+	conn := RedisConn.Get()
+	defer conn.Close()
+
+	return redis.IntMap(conn.Do("ZREVRANGE", key, start, stop, "WITHSCORES"))
+}
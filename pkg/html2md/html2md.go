@@ -0,0 +1,223 @@
+package html2md
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// Convert turns an HTML fragment into Markdown. Line breaks in text are kept, as
+// WordPress and similar editors store paragraphs as blank lines rather than <p>.
+// Markup without a Markdown equivalent, such as tables, is kept as HTML
+func Convert(fragment string) (string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return "", err
+	}
+
+	c := &converter{}
+	for _, n := range nodes {
+		c.node(n)
+	}
+
+	out := blankLines.ReplaceAllString(c.buf.String(), "\n\n")
+	return strings.TrimSpace(out) + "\n", nil
+}
+
+type converter struct {
+	buf   bytes.Buffer
+	lists []int
+}
+
+func (c *converter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.buf.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		c.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style:
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Figure:
+		c.block(n)
+	case atom.Figcaption:
+		c.buf.WriteString("\n\n*")
+		c.children(n)
+		c.buf.WriteString("*\n\n")
+	case atom.Br:
+		c.buf.WriteString("  \n")
+	case atom.Hr:
+		c.buf.WriteString("\n\n---\n\n")
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(n.Data[1:])
+		c.buf.WriteString("\n\n" + strings.Repeat("#", level) + " ")
+		c.buf.WriteString(strings.TrimSpace(c.inline(n)))
+		c.buf.WriteString("\n\n")
+	case atom.Strong, atom.B:
+		c.wrap(n, "**")
+	case atom.Em, atom.I:
+		c.wrap(n, "*")
+	case atom.Del, atom.S, atom.Strike:
+		c.wrap(n, "~~")
+	case atom.Code:
+		c.wrap(n, "`")
+	case atom.A:
+		href := attr(n, "href")
+		text := c.inline(n)
+		if href == "" {
+			c.buf.WriteString(text)
+		} else {
+			c.buf.WriteString("[" + text + "](" + href + ")")
+		}
+	case atom.Img:
+		c.buf.WriteString("![" + attr(n, "alt") + "](" + attr(n, "src") + ")")
+	case atom.Pre:
+		c.pre(n)
+	case atom.Blockquote:
+		quoted := (&converter{}).convert(n)
+		c.buf.WriteString("\n\n")
+		for _, line := range strings.Split(strings.TrimSpace(quoted), "\n") {
+			c.buf.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+		c.buf.WriteString("\n")
+	case atom.Ul, atom.Ol:
+		c.list(n)
+	case atom.Li:
+		c.item(n)
+	case atom.Table, atom.Iframe, atom.Video, atom.Audio:
+		c.buf.WriteString("\n\n")
+		html.Render(&c.buf, n)
+		c.buf.WriteString("\n\n")
+	default:
+		c.children(n)
+	}
+}
+
+func (c *converter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child)
+	}
+}
+
+func (c *converter) convert(n *html.Node) string {
+	c.children(n)
+	return c.buf.String()
+}
+
+// inline renders the children of n on their own, for markup that needs to wrap them
+func (c *converter) inline(n *html.Node) string {
+	return (&converter{lists: c.lists}).convert(n)
+}
+
+func (c *converter) block(n *html.Node) {
+	c.buf.WriteString("\n\n")
+	c.children(n)
+	c.buf.WriteString("\n\n")
+}
+
+func (c *converter) wrap(n *html.Node, marker string) {
+	text := c.inline(n)
+	if strings.TrimSpace(text) == "" {
+		c.buf.WriteString(text)
+		return
+	}
+
+	c.buf.WriteString(marker + text + marker)
+}
+
+func (c *converter) pre(n *html.Node) {
+	lang := language(n)
+	if code := n.FirstChild; code != nil && code.DataAtom == atom.Code && code.NextSibling == nil {
+		if l := language(code); l != "" {
+			lang = l
+		}
+	}
+
+	c.buf.WriteString("\n\n```" + lang + "\n")
+	c.buf.WriteString(strings.Trim(text(n), "\n"))
+	c.buf.WriteString("\n```\n\n")
+}
+
+func (c *converter) list(n *html.Node) {
+	start := 0
+	if n.DataAtom == atom.Ol {
+		start = 1
+		if s, err := strconv.Atoi(attr(n, "start")); err == nil {
+			start = s
+		}
+	}
+
+	c.buf.WriteString("\n\n")
+	c.lists = append(c.lists, start)
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom == atom.Li {
+			c.item(child)
+		}
+	}
+	c.lists = c.lists[:len(c.lists)-1]
+	c.buf.WriteString("\n")
+}
+
+func (c *converter) item(n *html.Node) {
+	depth := len(c.lists)
+	marker := "- "
+	if depth > 0 && c.lists[depth-1] > 0 {
+		marker = strconv.Itoa(c.lists[depth-1]) + ". "
+		c.lists[depth-1]++
+	}
+
+	// nested lists and paragraphs line up under the text of the item
+	lines := strings.Split(strings.TrimSpace(c.inline(n)), "\n")
+	for i, line := range lines {
+		if i > 0 && line != "" {
+			lines[i] = strings.Repeat(" ", len(marker)) + line
+		}
+	}
+	c.buf.WriteString(marker + strings.Join(lines, "\n") + "\n")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+// language reads a code language from classes like "language-go" or "lang-go"
+func language(n *html.Node) string {
+	for _, class := range strings.Fields(attr(n, "class")) {
+		for _, prefix := range []string{"language-", "lang-", "brush:"} {
+			if strings.HasPrefix(class, prefix) {
+				return strings.TrimSuffix(strings.TrimPrefix(class, prefix), ";")
+			}
+		}
+	}
+
+	return ""
+}
+
+func text(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var buf bytes.Buffer
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		buf.WriteString(text(child))
+	}
+	return buf.String()
+}
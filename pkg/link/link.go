@@ -0,0 +1,44 @@
+package link
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/feed"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// GetHomeFullUrl get the public address of the blog
+func GetHomeFullUrl() string {
+	return setting.AppSetting.PrefixUrl + "/"
+}
+
+// GetArticleFullUrl get the public address of an article
+func GetArticleFullUrl(id int) string {
+	return setting.AppSetting.PrefixUrl + "/articles/" + strconv.Itoa(id)
+}
+
+// GetTagFullUrl get the public address of a tag
+func GetTagFullUrl(id int) string {
+	return setting.AppSetting.PrefixUrl + "/tags/" + strconv.Itoa(id)
+}
+
+// GetAuthorFullUrl get the public address of an author
+func GetAuthorFullUrl(name string) string {
+	return setting.AppSetting.PrefixUrl + "/authors/" + url.PathEscape(name)
+}
+
+// GetFeedFullUrl get the public address of the site feed
+func GetFeedFullUrl(format string) string {
+	return setting.AppSetting.PrefixUrl + "/feed/" + feed.FileName(format)
+}
+
+// GetTagFeedFullUrl get the public address of a tag feed
+func GetTagFeedFullUrl(id int, format string) string {
+	return GetTagFullUrl(id) + "/feed/" + feed.FileName(format)
+}
+
+// GetAuthorFeedFullUrl get the public address of an author feed
+func GetAuthorFeedFullUrl(name, format string) string {
+	return GetAuthorFullUrl(name) + "/feed/" + feed.FileName(format)
+}
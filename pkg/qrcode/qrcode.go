@@ -1,13 +1,14 @@
 package qrcode
 
 import (
+	"bytes"
 	"image/jpeg"
 
 	"github.com/boombuler/barcode"
 	"github.com/boombuler/barcode/qr"
 
-	"github.com/EDDYCJY/go-gin-example/pkg/file"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
 	"github.com/EDDYCJY/go-gin-example/pkg/util"
 )
 
@@ -41,14 +42,9 @@ func GetQrCodePath() string {
 	return setting.AppSetting.QrCodeSavePath
 }
 
-// GetQrCodeFullPath get full save path
-func GetQrCodeFullPath() string {
-	return setting.AppSetting.RuntimeRootPath + setting.AppSetting.QrCodeSavePath
-}
-
 // GetQrCodeFullUrl get the full access path
 func GetQrCodeFullUrl(name string) string {
-	return setting.AppSetting.PrefixUrl + "/" + GetQrCodePath() + name
+	return storage.Posters.URL(name)
 }
 
 // GetQrCodeFileName get qr file name
@@ -61,11 +57,10 @@ func (q *QrCode) GetQrCodeExt() string {
 	return q.Ext
 }
 
-// Encode generate QR code
-func (q *QrCode) Encode(path string) (string, string, error) {
+// Encode generate QR code, storing it among the posters unless it is there already
+func (q *QrCode) Encode() (string, string, error) {
 	name := GetQrCodeFileName(q.URL) + q.GetQrCodeExt()
-	src := path + name
-	if file.CheckNotExist(src) == true {
+	if !storage.Exists(storage.Posters, name) {
 		code, err := qr.Encode(q.URL, q.Level, q.Mode)
 		if err != nil {
 			return "", "", err
@@ -76,17 +71,17 @@ func (q *QrCode) Encode(path string) (string, string, error) {
 			return "", "", err
 		}
 
-		f, err := file.MustOpen(name, path)
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, code, nil)
 		if err != nil {
 			return "", "", err
 		}
-		defer f.Close()
 
-		err = jpeg.Encode(f, code, nil)
+		err = storage.Posters.Put(name, &buf)
 		if err != nil {
 			return "", "", err
 		}
 	}
 
-	return name, path, nil
+	return name, GetQrCodePath(), nil
 }
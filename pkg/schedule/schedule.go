@@ -0,0 +1,35 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+)
+
+// Every runs fn in the background once per interval, logging any error it returns
+func Every(name string, interval time.Duration, fn func() error) {
+	if interval <= 0 {
+		logging.Warn("schedule.Every skip job without interval:", name)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := fn(); err != nil {
+				logging.Error("schedule job", name, "err:", err)
+			}
+		}
+	}()
+}
+
+// Now runs fn once in the background, logging any error it returns
+func Now(name string, fn func() error) {
+	go func() {
+		if err := fn(); err != nil {
+			logging.Error("schedule job", name, "err:", err)
+		}
+	}()
+}
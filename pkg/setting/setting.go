@@ -2,6 +2,7 @@ package setting
 
 import (
 	"log"
+	"strings"
 	"time"
 
 	"github.com/go-ini/ini"
@@ -19,6 +20,7 @@ type App struct {
 	ImageAllowExts []string
 
 	ExportSavePath string
+	BackupSavePath string
 	QrCodeSavePath string
 	FontSavePath   string
 
@@ -60,6 +62,157 @@ type Redis struct {
 
 var RedisSetting = &Redis{}
 
+type Reaction struct {
+	Kinds             []string
+	ReconcileInterval time.Duration
+}
+
+var ReactionSetting = &Reaction{}
+
+type Related struct {
+	Limit           int
+	TagWeight       float64
+	TextWeight      float64
+	CoViewWeight    float64
+	CoViewHistory   int
+	CoViewExpire    int
+	RefreshInterval time.Duration
+}
+
+var RelatedSetting = &Related{}
+
+type Feed struct {
+	Title       string
+	Description string
+	Limit       int
+	CacheExpire int
+}
+
+var FeedSetting = &Feed{}
+
+type Sitemap struct {
+	SavePath        string
+	RefreshInterval time.Duration
+}
+
+var SitemapSetting = &Sitemap{}
+
+type Robots struct {
+	UserAgent  string
+	Allow      []string
+	Disallow   []string
+	CrawlDelay int
+}
+
+var RobotsSetting = &Robots{}
+
+type Frontend struct {
+	Enabled   bool
+	Title     string
+	ThemePath string
+	Theme     string
+}
+
+var FrontendSetting = &Frontend{}
+
+type Import struct {
+	MaxSize    int64
+	DefaultTag string
+}
+
+var ImportSetting = &Import{}
+
+type Csv struct {
+	Delimiter string
+	BOM       bool
+}
+
+var CsvSetting = &Csv{}
+
+type Export struct {
+	Workers         int
+	QueueSize       int
+	UrlExpire       time.Duration
+	Retention       time.Duration
+	JanitorInterval time.Duration
+	BatchSize       int
+}
+
+var ExportSetting = &Export{}
+
+type Image struct {
+	Variants  []string
+	Quality   int
+	CachePath string
+	MaxWidth  int
+	MaxHeight int
+	Resizers  int
+}
+
+var ImageSetting = &Image{}
+
+type Storage struct {
+	Type      string
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	PublicUrl string
+}
+
+var StorageSetting = &Storage{}
+
+type Gc struct {
+	Interval       time.Duration
+	GracePeriod    time.Duration
+	Retention      time.Duration
+	QuarantinePath string
+}
+
+var GcSetting = &Gc{}
+
+type Tus struct {
+	SavePath        string
+	MaxSize         int
+	Expire          time.Duration
+	JanitorInterval time.Duration
+}
+
+var TusSetting = &Tus{}
+
+type Attachment struct {
+	SavePath string
+
+	// Types are read from the [attachment.<type>] sections
+	Types map[string]*AttachmentType `ini:"-"`
+}
+
+type AttachmentType struct {
+	MimeTypes []string
+	Exts      []string
+	MaxSize   int
+}
+
+var AttachmentSetting = &Attachment{}
+
+type Quota struct {
+	Roles       []string
+	DefaultRole string
+	RateLimit   int
+	RateWindow  time.Duration
+}
+
+var QuotaSetting = &Quota{}
+
+type Webdav struct {
+	MaxAuthFailures   int
+	AuthFailureWindow time.Duration
+}
+
+var WebdavSetting = &Webdav{}
+
 var cfg *ini.File
 
 // Setup initialize the configuration instance
@@ -74,11 +227,57 @@ func Setup() {
 	mapTo("server", ServerSetting)
 	mapTo("database", DatabaseSetting)
 	mapTo("redis", RedisSetting)
+	mapTo("reaction", ReactionSetting)
+	mapTo("related", RelatedSetting)
+	mapTo("feed", FeedSetting)
+	mapTo("sitemap", SitemapSetting)
+	mapTo("robots", RobotsSetting)
+	mapTo("frontend", FrontendSetting)
+	mapTo("import", ImportSetting)
+	mapTo("csv", CsvSetting)
+	mapTo("export", ExportSetting)
+	mapTo("image", ImageSetting)
+	mapTo("storage", StorageSetting)
+	mapTo("gc", GcSetting)
+	mapTo("tus", TusSetting)
+	mapTo("attachment", AttachmentSetting)
+	mapAttachmentTypes()
+	mapTo("quota", QuotaSetting)
+	mapTo("webdav", WebdavSetting)
 
 	AppSetting.ImageMaxSize = AppSetting.ImageMaxSize * 1024 * 1024
+	ImportSetting.MaxSize = ImportSetting.MaxSize * 1024 * 1024
 	ServerSetting.ReadTimeout = ServerSetting.ReadTimeout * time.Second
 	ServerSetting.WriteTimeout = ServerSetting.WriteTimeout * time.Second
 	RedisSetting.IdleTimeout = RedisSetting.IdleTimeout * time.Second
+	ReactionSetting.ReconcileInterval = ReactionSetting.ReconcileInterval * time.Second
+	RelatedSetting.RefreshInterval = RelatedSetting.RefreshInterval * time.Second
+	SitemapSetting.RefreshInterval = SitemapSetting.RefreshInterval * time.Second
+	ExportSetting.UrlExpire = ExportSetting.UrlExpire * time.Second
+	ExportSetting.Retention = ExportSetting.Retention * time.Second
+	ExportSetting.JanitorInterval = ExportSetting.JanitorInterval * time.Second
+	GcSetting.Interval = GcSetting.Interval * time.Second
+	GcSetting.GracePeriod = GcSetting.GracePeriod * time.Second
+	GcSetting.Retention = GcSetting.Retention * time.Second
+	TusSetting.MaxSize = TusSetting.MaxSize * 1024 * 1024
+	TusSetting.Expire = TusSetting.Expire * time.Second
+	TusSetting.JanitorInterval = TusSetting.JanitorInterval * time.Second
+	QuotaSetting.RateWindow = QuotaSetting.RateWindow * time.Second
+	WebdavSetting.AuthFailureWindow = WebdavSetting.AuthFailureWindow * time.Second
+}
+
+// mapAttachmentTypes map the child sections of [attachment], one for each type
+func mapAttachmentTypes() {
+	AttachmentSetting.Types = make(map[string]*AttachmentType)
+	for _, section := range cfg.Section("attachment").ChildSections() {
+		t := &AttachmentType{}
+		if err := section.MapTo(t); err != nil {
+			log.Fatalf("Cfg.MapTo %s err: %v", section.Name(), err)
+		}
+		t.MaxSize = t.MaxSize * 1024 * 1024
+
+		AttachmentSetting.Types[strings.TrimPrefix(section.Name(), "attachment.")] = t
+	}
 }
 
 // mapTo map section
@@ -0,0 +1,102 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// MaxURLs is the most URLs the sitemap protocol allows in a single file
+const MaxURLs = 50000
+
+const EXT = ".xml"
+
+type URLSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	ImageNS string   `xml:"xmlns:image,attr"`
+	URLs    []URL    `xml:"url"`
+}
+
+type URL struct {
+	Loc     string  `xml:"loc"`
+	LastMod string  `xml:"lastmod,omitempty"`
+	Images  []Image `xml:"image:image"`
+}
+
+type Image struct {
+	Loc string `xml:"image:loc"`
+}
+
+type Index struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	NS       string   `xml:"xmlns,attr"`
+	Sitemaps []Entry  `xml:"sitemap"`
+}
+
+type Entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// NewURL describes a page, leaving lastmod out when the time is unknown
+func NewURL(loc string, lastMod int, images ...string) URL {
+	u := URL{Loc: loc}
+	if lastMod > 0 {
+		u.LastMod = FormatTime(time.Unix(int64(lastMod), 0))
+	}
+	for _, image := range images {
+		if image != "" {
+			u.Images = append(u.Images, Image{Loc: image})
+		}
+	}
+
+	return u
+}
+
+// FormatTime formats a time in the W3C datetime format the protocol expects
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// MarshalURLSet encodes a sitemap file
+func MarshalURLSet(urls []URL) ([]byte, error) {
+	return marshal(URLSet{
+		NS:      "http://www.sitemaps.org/schemas/sitemap/0.9",
+		ImageNS: "http://www.google.com/schemas/sitemap-image/1.1",
+		URLs:    urls,
+	})
+}
+
+// MarshalIndex encodes a sitemap index file
+func MarshalIndex(entries []Entry) ([]byte, error) {
+	return marshal(Index{
+		NS:       "http://www.sitemaps.org/schemas/sitemap/0.9",
+		Sitemaps: entries,
+	})
+}
+
+// GetSitemapPath get the relative save path of the sitemap files
+func GetSitemapPath() string {
+	return setting.SitemapSetting.SavePath
+}
+
+// GetSitemapFullPath get the full save path of the sitemap files
+func GetSitemapFullPath() string {
+	return setting.AppSetting.RuntimeRootPath + GetSitemapPath()
+}
+
+// GetSitemapFullUrl get the full access path of a sitemap part
+func GetSitemapFullUrl(name string) string {
+	return setting.AppSetting.PrefixUrl + "/sitemaps/" + name
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), body...), nil
+}
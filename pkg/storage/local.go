@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/file"
+)
+
+// Local keeps files in a directory on local disk
+type Local struct {
+	Root    string
+	BaseUrl string
+}
+
+// NewLocal opens the directory root, whose files are served at baseUrl
+func NewLocal(root, baseUrl string) *Local {
+	return &Local{Root: root, BaseUrl: strings.TrimSuffix(baseUrl, "/")}
+}
+
+func (l *Local) path(name string) (string, error) {
+	clean, err := cleanName(name)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(l.Root, filepath.FromSlash(clean)), nil
+}
+
+func (l *Local) Put(name string, r io.Reader) error {
+	dst, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(dst)
+	if err := file.IsNotExistMkDir(dir); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, ".put-")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	// renaming makes the file appear whole to concurrent readers
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// Get opens a file. The reader is an *os.File, so it can also seek
+func (l *Local) Get(name string) (io.ReadCloser, error) {
+	src, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(src)
+}
+
+func (l *Local) Stat(name string) (*Info, error) {
+	src, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, &os.PathError{Op: "stat", Path: src, Err: os.ErrNotExist}
+	}
+
+	clean, _ := cleanName(name)
+	return &Info{Name: clean, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (l *Local) Delete(name string) error {
+	src, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(src); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// List walks the directory, skipping dot files, which are still being written
+func (l *Local) List(prefix string) ([]Info, error) {
+	var list []Info
+	err := filepath.Walk(l.Root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == l.Root {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.Root, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if strings.HasPrefix(name, prefix) {
+			list = append(list, Info{Name: name, Size: info.Size(), ModTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+func (l *Local) URL(name string) string {
+	return l.BaseUrl + "/" + strings.TrimPrefix(name, "/")
+}
@@ -0,0 +1,446 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+const (
+	amzDateFormat   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	emptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3 keeps files in a bucket of Amazon S3 or any service speaking its API, such
+// as MinIO, under a key prefix. Requests are signed with AWS Signature Version 4
+type S3 struct {
+	Endpoint  *url.URL
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket as endpoint/bucket rather than bucket.endpoint
+	PathStyle bool
+	Prefix    string
+	PublicUrl string
+	Client    *http.Client
+}
+
+// S3Error is an error response of the service
+type S3Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+// NewS3 opens the configured bucket, keeping files under the key prefix
+func NewS3(prefix string) (*S3, error) {
+	s := setting.StorageSetting
+	endpoint, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Host == "" || s.Bucket == "" {
+		return nil, errors.New("s3 storage needs an endpoint and a bucket")
+	}
+
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3{
+		Endpoint:  endpoint,
+		Region:    region,
+		Bucket:    s.Bucket,
+		AccessKey: s.AccessKey,
+		SecretKey: s.SecretKey,
+		PathStyle: s.PathStyle,
+		Prefix:    prefix,
+		PublicUrl: strings.TrimSuffix(s.PublicUrl, "/"),
+		Client:    http.DefaultClient,
+	}, nil
+}
+
+// objectUrl is the address of a key, or of the bucket itself when key is ""
+func (s *S3) objectUrl(key string) *url.URL {
+	u := *s.Endpoint
+	p := u.Path + "/"
+	if s.PathStyle {
+		p += s.Bucket + "/"
+	} else {
+		u.Host = s.Bucket + "." + u.Host
+	}
+	p += key
+
+	u.Path = p
+	u.RawPath = uriEncode(p, true)
+	return &u
+}
+
+func (s *S3) key(name string) (string, error) {
+	clean, err := cleanName(name)
+	if err != nil {
+		return "", err
+	}
+
+	return s.Prefix + clean, nil
+}
+
+func (s *S3) Put(name string, r io.Reader) error {
+	key, err := s.key(name)
+	if err != nil {
+		return err
+	}
+
+	body, size, cleanup, err := sized(r)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if size == 0 {
+		body = http.NoBody
+	}
+	req, err := http.NewRequest(http.MethodPut, s.objectUrl(key).String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+func (s *S3) Get(name string) (io.ReadCloser, error) {
+	resp, err := s.object(http.MethodGet, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (s *S3) Stat(name string) (*Info, error) {
+	resp, err := s.object(http.MethodHead, name)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	clean, _ := cleanName(name)
+	info := &Info{Name: clean, Size: resp.ContentLength}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = modTime
+	}
+
+	return info, nil
+}
+
+func (s *S3) Delete(name string) error {
+	key, err := s.key(name)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodDelete, s.objectUrl(key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, emptyPayload)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// List lists the keys below the prefix page by page, skipping dot files as the
+// local storage does
+func (s *S3) List(prefix string) ([]Info, error) {
+	var list []Info
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", s.Prefix+prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		u := s.objectUrl("")
+		u.RawQuery = query.Encode()
+		req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.do(req, emptyPayload)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range result.Contents {
+			name := strings.TrimPrefix(c.Key, s.Prefix)
+			if strings.HasPrefix(path.Base(name), ".") || strings.HasSuffix(name, "/") {
+				continue
+			}
+			list = append(list, Info{Name: name, Size: c.Size, ModTime: c.LastModified})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+// URL is the public address of the object, which the bucket policy has to allow
+// anonymous reads of
+func (s *S3) URL(name string) string {
+	key := s.Prefix + strings.TrimPrefix(name, "/")
+	if s.PublicUrl != "" {
+		return s.PublicUrl + "/" + uriEncode(key, true)
+	}
+
+	return s.objectUrl(key).String()
+}
+
+// object sends a request for the object stored under the name
+func (s *S3) object(method, name string) (*http.Response, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, s.objectUrl(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.do(req, emptyPayload)
+}
+
+// do signs and sends a request, turning error responses into errors. Missing
+// keys are reported as os.ErrNotExist
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, time.Now())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &os.PathError{Op: strings.ToLower(req.Method), Path: req.URL.Path, Err: os.ErrNotExist}
+	}
+
+	s3Err := &S3Error{StatusCode: resp.StatusCode}
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	xml.Unmarshal(data, s3Err)
+	return nil, s3Err
+}
+
+// sign adds the Authorization header of AWS Signature Version 4 to a request,
+// signing its host, its x-amz-* headers and the other headers already set
+func (s *S3) sign(req *http.Request, payloadHash string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format(amzDateFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSha256(key, s.Region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name, false)+"="+uriEncode(value, false))
+		}
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters, and the
+// slashes of a path when keepSlash is set, as signing requires
+func uriEncode(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sized returns a body whose length is known up front, as uploads need one:
+// seekable readers are measured, anything else is spooled to a temporary file
+// which cleanup removes
+func sized(r io.Reader) (io.Reader, int64, func(), error) {
+	nop := func() {}
+	switch v := r.(type) {
+	case *bytes.Reader:
+		return v, int64(v.Len()), nop, nil
+	case *bytes.Buffer:
+		return v, int64(v.Len()), nop, nil
+	case *strings.Reader:
+		return v, int64(v.Len()), nop, nil
+	case io.ReadSeeker:
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			break
+		}
+		end, err := v.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if _, err := v.Seek(offset, io.SeekStart); err != nil {
+			return nil, 0, nil, err
+		}
+		return v, end - offset, nop, nil
+	}
+
+	f, err := ioutil.TempFile("", "s3-put-")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	size, err := io.Copy(f, r)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+
+	return f, size, cleanup, nil
+}
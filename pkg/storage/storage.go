@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+const (
+	TYPE_LOCAL = "local"
+	TYPE_S3    = "s3"
+)
+
+var ErrInvalidName = errors.New("invalid storage name")
+
+// Info describes a stored file
+type Info struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage keeps the files of one area, such as the uploaded images, under
+// slash separated names relative to it. Missing files are reported with an
+// error satisfying os.IsNotExist
+type Storage interface {
+	// Put stores the content of r under the name, replacing any file there
+	Put(name string, r io.Reader) error
+	Get(name string) (io.ReadCloser, error)
+	Stat(name string) (*Info, error)
+	// Delete removes a file, doing nothing if there is none
+	Delete(name string) error
+	// List lists the files whose names start with the prefix
+	List(prefix string) ([]Info, error)
+	// URL is where clients can fetch the file
+	URL(name string) string
+}
+
+var (
+	Images      Storage
+	Exports     Storage
+	Posters     Storage
+	Quarantine  Storage
+	Attachments Storage
+)
+
+// Setup opens the storage of each area with the configured backend
+func Setup() {
+	areas := []struct {
+		storage  *Storage
+		savePath string
+	}{
+		{&Images, setting.AppSetting.ImageSavePath},
+		{&Exports, setting.AppSetting.ExportSavePath},
+		{&Posters, setting.AppSetting.QrCodeSavePath},
+		{&Quarantine, setting.GcSetting.QuarantinePath},
+		{&Attachments, setting.AttachmentSetting.SavePath},
+	}
+
+	for _, area := range areas {
+		s, err := New(area.savePath)
+		if err != nil {
+			log.Fatalf("storage.Setup err: %v", err)
+		}
+		*area.storage = s
+	}
+}
+
+// New opens the storage of an area by its save path: a directory below
+// RuntimeRootPath on local disk, or a key prefix in the S3 bucket
+func New(savePath string) (Storage, error) {
+	switch setting.StorageSetting.Type {
+	case TYPE_S3:
+		return NewS3(savePath)
+	case TYPE_LOCAL, "":
+		return NewLocal(setting.AppSetting.RuntimeRootPath+savePath, setting.AppSetting.PrefixUrl+"/"+savePath), nil
+	}
+
+	return nil, errors.New("unknown storage type: " + setting.StorageSetting.Type)
+}
+
+// Exists checks if a file is stored under the name
+func Exists(s Storage, name string) bool {
+	_, err := s.Stat(name)
+	return err == nil
+}
+
+// cleanName makes a name relative to the storage, refusing names that would leave it
+func cleanName(name string) (string, error) {
+	clean := path.Clean("/" + name)[1:]
+	if clean == "" || strings.HasPrefix(path.Base(clean), ".") {
+		return "", ErrInvalidName
+	}
+
+	return clean, nil
+}
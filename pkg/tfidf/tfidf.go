@@ -0,0 +1,117 @@
+package tfidf
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Vector is a sparse term weight vector
+type Vector map[string]float64
+
+// Corpus holds the document frequencies used to weigh terms
+type Corpus struct {
+	docs int
+	df   map[string]int
+}
+
+// NewCorpus builds a corpus from tokenized documents
+func NewCorpus(documents [][]string) *Corpus {
+	c := &Corpus{df: make(map[string]int)}
+	for _, terms := range documents {
+		c.docs++
+		seen := make(map[string]bool, len(terms))
+		for _, term := range terms {
+			if !seen[term] {
+				seen[term] = true
+				c.df[term]++
+			}
+		}
+	}
+
+	return c
+}
+
+// Vector computes the normalized TF-IDF vector of a tokenized document
+func (c *Corpus) Vector(terms []string) Vector {
+	tf := make(map[string]int, len(terms))
+	for _, term := range terms {
+		tf[term]++
+	}
+
+	v := make(Vector, len(tf))
+	var norm float64
+	for term, n := range tf {
+		idf := math.Log(float64(c.docs+1)/float64(c.df[term]+1)) + 1
+		w := float64(n) / float64(len(terms)) * idf
+		v[term] = w
+		norm += w * w
+	}
+
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for term := range v {
+			v[term] /= norm
+		}
+	}
+
+	return v
+}
+
+// Cosine returns the cosine similarity of two normalized vectors
+func Cosine(a, b Vector) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	var dot float64
+	for term, w := range a {
+		dot += w * b[term]
+	}
+
+	return dot
+}
+
+// Tokenize splits text into lower-cased words, turning runs of CJK characters
+// into overlapping bigrams since they are not separated by spaces
+func Tokenize(text string) []string {
+	var (
+		terms []string
+		word  []rune
+		cjk   []rune
+	)
+
+	flushWord := func() {
+		if len(word) > 1 {
+			terms = append(terms, string(word))
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			terms = append(terms, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			terms = append(terms, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return terms
+}
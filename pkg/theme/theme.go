@@ -0,0 +1,166 @@
+package theme
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/link"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+// Theme is a set of page templates, each combined with the shared layouts and partials
+type Theme struct {
+	dir   string
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// Page is the data every theme page receives
+type Page struct {
+	SiteTitle string
+	Title     string
+	Data      interface{}
+
+	Page    int
+	PrevUrl string
+	NextUrl string
+}
+
+var current *Theme
+
+// Setup loads the configured theme when the HTML frontend is enabled. A theme
+// that cannot be loaded turns the frontend off rather than stopping the API
+func Setup() {
+	if !setting.FrontendSetting.Enabled {
+		return
+	}
+
+	t, err := New(GetThemeFullPath())
+	if err != nil {
+		logging.Error("theme.Setup frontend disabled, err:", err)
+		setting.FrontendSetting.Enabled = false
+		return
+	}
+
+	current = t
+}
+
+// New loads the theme in dir
+func New(dir string) (*Theme, error) {
+	t := &Theme{dir: dir}
+	if err := t.Load(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// GetThemeFullPath get the directory of the configured theme
+func GetThemeFullPath() string {
+	return setting.FrontendSetting.ThemePath + setting.FrontendSetting.Theme + "/"
+}
+
+// GetStaticFullPath get the directory of the theme's static assets
+func GetStaticFullPath() string {
+	return GetThemeFullPath() + "static/"
+}
+
+// Load parses layouts/*.html and partials/*.html once per page in pages/*.html
+func (t *Theme) Load() error {
+	shared, err := template.New("").Funcs(funcMap()).ParseGlob(filepath.Join(t.dir, "layouts", "*.html"))
+	if err != nil {
+		return err
+	}
+
+	partials, err := filepath.Glob(filepath.Join(t.dir, "partials", "*.html"))
+	if err != nil {
+		return err
+	}
+	if len(partials) > 0 {
+		if shared, err = shared.ParseFiles(partials...); err != nil {
+			return err
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(t.dir, "pages", "*.html"))
+	if err != nil {
+		return err
+	}
+
+	pages := make(map[string]*template.Template, len(files))
+	for _, f := range files {
+		page, err := shared.Clone()
+		if err != nil {
+			return err
+		}
+		if page, err = page.ParseFiles(f); err != nil {
+			return err
+		}
+		pages[strings.TrimSuffix(filepath.Base(f), ".html")] = page
+	}
+
+	t.mu.Lock()
+	t.pages = pages
+	t.mu.Unlock()
+
+	return nil
+}
+
+// Render executes the base layout with the named page
+func (t *Theme) Render(w io.Writer, name string, data interface{}) error {
+	t.mu.RLock()
+	page, ok := t.pages[name]
+	t.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("theme.Render page %s not found in %s", name, t.dir)
+	}
+
+	return page.ExecuteTemplate(w, "base", data)
+}
+
+// Render renders a page of the configured theme, reloading the theme
+// first in debug mode so template edits show up without a restart
+func Render(w io.Writer, name string, data interface{}) error {
+	if current == nil {
+		return fmt.Errorf("theme.Render theme is not set up")
+	}
+
+	if setting.ServerSetting.RunMode == "debug" {
+		if err := current.Load(); err != nil {
+			return err
+		}
+	}
+
+	return current.Render(w, name, data)
+}
+
+func funcMap() template.FuncMap {
+	return template.FuncMap{
+		"date": func(unix int) string {
+			return time.Unix(int64(unix), 0).Format("2006-01-02")
+		},
+		"paragraphs": func(text string) []string {
+			var paragraphs []string
+			for _, p := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n\n") {
+				if p = strings.TrimSpace(p); p != "" {
+					paragraphs = append(paragraphs, p)
+				}
+			}
+			return paragraphs
+		},
+		"articleUrl": link.GetArticleFullUrl,
+		"tagUrl":     link.GetTagFullUrl,
+		"authorUrl":  link.GetAuthorFullUrl,
+		"homeUrl":    link.GetHomeFullUrl,
+		"feedUrl":    link.GetFeedFullUrl,
+		"asset": func(path string) string {
+			return setting.AppSetting.PrefixUrl + "/static/" + strings.TrimPrefix(path, "/")
+		},
+	}
+}
@@ -1,31 +1,30 @@
 package upload
 
 import (
-	"fmt"
-	"log"
-	"mime/multipart"
-	"os"
-	"path"
+	"crypto/sha256"
+	"encoding/hex"
 	"strings"
 
 	"github.com/EDDYCJY/go-gin-example/pkg/file"
-	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
-	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
 )
 
 // GetImageFullUrl get the full access path
 func GetImageFullUrl(name string) string {
-	return setting.AppSetting.PrefixUrl + "/" + GetImagePath() + name
+	return storage.Images.URL(name)
 }
 
-// GetImageName get image name
-func GetImageName(name string) string {
-	ext := path.Ext(name)
-	fileName := strings.TrimSuffix(name, ext)
-	fileName = util.EncodeMD5(fileName)
+// GetImageHash get the content hash an image is stored under
+func GetImageHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
 
-	return fileName + ext
+// GetHashName get the name of an image stored under its content hash, in a
+// directory named by the first two digits so that no directory grows too large
+func GetHashName(hash, ext string) string {
+	return hash[:2] + "/" + hash + strings.ToLower(ext)
 }
 
 // GetImagePath get save path
@@ -33,51 +32,7 @@ func GetImagePath() string {
 	return setting.AppSetting.ImageSavePath
 }
 
-// GetImageFullPath get full save path
-func GetImageFullPath() string {
-	return setting.AppSetting.RuntimeRootPath + GetImagePath()
-}
-
 // CheckImageExt check image file ext
 func CheckImageExt(fileName string) bool {
-	ext := file.GetExt(fileName)
-	for _, allowExt := range setting.AppSetting.ImageAllowExts {
-		if strings.ToUpper(allowExt) == strings.ToUpper(ext) {
-			return true
-		}
-	}
-
-	return false
-}
-
-// CheckImageSize check image size
-func CheckImageSize(f multipart.File) bool {
-	size, err := file.GetSize(f)
-	if err != nil {
-		log.Println(err)
-		logging.Warn(err)
-		return false
-	}
-
-	return size <= setting.AppSetting.ImageMaxSize
-}
-
-// CheckImage check if the file exists
-func CheckImage(src string) error {
-	dir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("os.Getwd err: %v", err)
-	}
-
-	err = file.IsNotExistMkDir(dir + "/" + src)
-	if err != nil {
-		return fmt.Errorf("file.IsNotExistMkDir err: %v", err)
-	}
-
-	perm := file.CheckPermission(src)
-	if perm == true {
-		return fmt.Errorf("file.CheckPermission Permission denied src: %s", src)
-	}
-
-	return nil
+	return GetImagePolicy().AllowsExt(file.GetExt(fileName))
 }
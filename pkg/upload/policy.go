@@ -0,0 +1,116 @@
+package upload
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+var (
+	ErrFileType     = errors.New("file type is not allowed")
+	ErrFileTooLarge = errors.New("file is too large")
+)
+
+const POLICY_IMAGE = "image"
+
+// Policy says which files of one type may be uploaded: those with one of the
+// extensions whose content is of one of the mime types, up to a size
+type Policy struct {
+	Type      string   `json:"type"`
+	MimeTypes []string `json:"mime_types"`
+	Exts      []string `json:"exts"`
+	MaxSize   int      `json:"max_size"`
+}
+
+// GetImagePolicy get the policy of images, which CleanImage checks further
+func GetImagePolicy() *Policy {
+	mimeTypes := make([]string, 0, len(imageExts))
+	for mimeType := range imageExts {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Strings(mimeTypes)
+
+	return &Policy{
+		Type:      POLICY_IMAGE,
+		MimeTypes: mimeTypes,
+		Exts:      setting.AppSetting.ImageAllowExts,
+		MaxSize:   setting.AppSetting.ImageMaxSize,
+	}
+}
+
+// GetAttachmentPolicies get the configured attachment types, by name
+func GetAttachmentPolicies() []*Policy {
+	policies := make([]*Policy, 0, len(setting.AttachmentSetting.Types))
+	for name, t := range setting.AttachmentSetting.Types {
+		policies = append(policies, &Policy{
+			Type:      name,
+			MimeTypes: t.MimeTypes,
+			Exts:      t.Exts,
+			MaxSize:   t.MaxSize,
+		})
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Type < policies[j].Type
+	})
+
+	return policies
+}
+
+// GetAttachmentPolicy get the attachment type accepting the extension, or nil if none does
+func GetAttachmentPolicy(ext string) *Policy {
+	for _, p := range GetAttachmentPolicies() {
+		if p.AllowsExt(ext) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// GetMaxAttachmentSize get the size of the largest attachment any type accepts
+func GetMaxAttachmentSize() int {
+	max := 0
+	for _, p := range GetAttachmentPolicies() {
+		if p.MaxSize > max {
+			max = p.MaxSize
+		}
+	}
+
+	return max
+}
+
+// AllowsExt reports whether the extension is one of the policy
+func (p *Policy) AllowsExt(ext string) bool {
+	for _, allowExt := range p.Exts {
+		if strings.EqualFold(strings.TrimSpace(allowExt), ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check checks the size of a file and the mime type sniffed from its first
+// bytes, which it returns. head needs no more than 512 bytes
+func (p *Policy) Check(head []byte, size int64) (string, error) {
+	if size > int64(p.MaxSize) {
+		return "", ErrFileTooLarge
+	}
+
+	contentType := http.DetectContentType(head)
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrFileType
+	}
+	for _, allowed := range p.MimeTypes {
+		if strings.EqualFold(strings.TrimSpace(allowed), mediaType) {
+			return contentType, nil
+		}
+	}
+
+	return "", ErrFileType
+}
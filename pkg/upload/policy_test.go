@@ -0,0 +1,77 @@
+package upload
+
+import (
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+func setupPolicies() {
+	setting.AttachmentSetting.Types = map[string]*setting.AttachmentType{
+		"document": {MimeTypes: []string{"application/pdf"}, Exts: []string{".pdf"}, MaxSize: 20},
+		"archive":  {MimeTypes: []string{"application/zip", "application/x-gzip"}, Exts: []string{".zip", " .gz"}, MaxSize: 50},
+		"code":     {MimeTypes: []string{"text/plain"}, Exts: []string{".txt", ".go"}, MaxSize: 1},
+	}
+}
+
+func TestGetAttachmentPolicy(t *testing.T) {
+	setupPolicies()
+
+	tests := []struct {
+		ext  string
+		want string
+	}{
+		{".pdf", "document"},
+		{".PDF", "document"},
+		{".gz", "archive"},
+		{".go", "code"},
+		{".exe", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if p := GetAttachmentPolicy(tt.ext); p != nil {
+			got = p.Type
+		}
+		if got != tt.want {
+			t.Errorf("GetAttachmentPolicy(%q) = %q, want %q", tt.ext, got, tt.want)
+		}
+	}
+
+	if got := GetMaxAttachmentSize(); got != 50 {
+		t.Errorf("GetMaxAttachmentSize() = %d, want 50", got)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	setupPolicies()
+
+	tests := []struct {
+		policy      string
+		head        string
+		size        int64
+		contentType string
+		err         error
+	}{
+		{"document", "%PDF-1.4\n", 20, "application/pdf", nil},
+		{"document", "%PDF-1.4\n", 21, "", ErrFileTooLarge},
+		{"document", "PK\x03\x04", 10, "", ErrFileType},
+		{"archive", "PK\x03\x04", 10, "application/zip", nil},
+		{"archive", "\x1f\x8b\x08", 10, "application/x-gzip", nil},
+		{"code", "package main\n", 1, "text/plain; charset=utf-8", nil},
+		{"code", "\x00\x01\x02\x03", 1, "", ErrFileType},
+		{"code", "<html><body>", 1, "", ErrFileType},
+	}
+
+	policies := make(map[string]*Policy)
+	for _, p := range GetAttachmentPolicies() {
+		policies[p.Type] = p
+	}
+	for _, tt := range tests {
+		contentType, err := policies[tt.policy].Check([]byte(tt.head), tt.size)
+		if contentType != tt.contentType || err != tt.err {
+			t.Errorf("%s Check(%q, %d) = %q, %v, want %q, %v", tt.policy, tt.head, tt.size, contentType, err, tt.contentType, tt.err)
+		}
+	}
+}
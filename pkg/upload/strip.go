@@ -0,0 +1,211 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// stripJPEG drops the segments of a JPEG file that carry metadata rather than
+// the picture: EXIF and XMP in APP1, IPTC in APP13, comments and the other
+// application segments, keeping JFIF, the ICC profile and the Adobe color
+// transform. It also returns the EXIF orientation, 0 when there is none.
+// Anything it cannot parse gives nil, as what it skipped may hide metadata
+// the decoder reads past
+func stripJPEG(data []byte) ([]byte, int) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	orientation := 0
+
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF {
+			return nil, 0
+		}
+		// markers may be padded with any number of 0xFF
+		for i < len(data) && data[i] == 0xFF {
+			i++
+		}
+		if i >= len(data) {
+			return nil, 0
+		}
+		marker := data[i]
+		i++
+
+		// standalone markers carry no length
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out = append(out, 0xFF, marker)
+			continue
+		}
+		if marker == 0xD9 {
+			return append(out, 0xFF, 0xD9), orientation
+		}
+
+		if i+2 > len(data) {
+			return nil, 0
+		}
+		length := int(binary.BigEndian.Uint16(data[i:]))
+		if length < 2 || i+length > len(data) {
+			return nil, 0
+		}
+		segment := data[i+2 : i+length]
+
+		// the entropy coded picture runs from the start of scan to the end
+		if marker == 0xDA {
+			return append(out, data[i-2:]...), orientation
+		}
+
+		keep := true
+		switch {
+		case marker == 0xE1:
+			if bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+				orientation = exifOrientation(segment[6:])
+			}
+			keep = false
+		case marker == 0xE2:
+			keep = bytes.HasPrefix(segment, []byte("ICC_PROFILE\x00"))
+		case marker == 0xE0, marker == 0xEE:
+		case marker >= 0xE3 && marker <= 0xEF, marker == 0xFE:
+			keep = false
+		}
+		if keep {
+			out = append(out, data[i-2:i+length]...)
+		}
+		i += length
+	}
+
+	return nil, 0
+}
+
+// exifOrientation reads the orientation tag from the first IFD of EXIF data
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for n := 0; n < count; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 0
+			}
+			return orientation
+		}
+	}
+
+	return 0
+}
+
+// pngMetadata are the ancillary PNG chunks holding text, EXIF and timestamps
+var pngMetadata = map[string]bool{
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"eXIf": true,
+	"tIME": true,
+}
+
+// stripPNG drops the metadata chunks of a PNG file. Anything it cannot parse
+// gives nil
+func stripPNG(data []byte) []byte {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(signature)) {
+		return nil
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, signature...)
+
+	i := len(signature)
+	for i+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) || end < i {
+			return nil
+		}
+
+		kind := string(data[i+4 : i+8])
+		if !pngMetadata[kind] {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+
+		if kind == "IEND" {
+			return out
+		}
+	}
+
+	return nil
+}
+
+// orient turns an image the way its EXIF orientation says it should be shown.
+// The pixels are moved four bytes at a time between RGBA buffers, decoded
+// JPEG images being converted to one first
+func orient(img image.Image, orientation int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	src, ok := img.(*image.RGBA)
+	if !ok || b.Min != (image.Point{}) {
+		src = image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	}
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		row := src.Pix[y*src.Stride : y*src.Stride+w*4]
+		for x := 0; x < w; x++ {
+			dx, dy := x, y
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			i := dy*dst.Stride + dx*4
+			copy(dst.Pix[i:i+4], row[x*4:x*4+4])
+		}
+	}
+
+	return dst
+}
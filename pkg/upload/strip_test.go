@@ -0,0 +1,130 @@
+package upload
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"reflect"
+	"testing"
+)
+
+func TestOrient(t *testing.T) {
+	// a 3x2 image whose pixels are told apart by their red value:
+	//	a b c
+	//	d e f
+	const a, b, c, d, e, f = 10, 20, 30, 40, 50, 60
+	src := [][]uint8{{a, b, c}, {d, e, f}}
+
+	tests := []struct {
+		orientation int
+		want        [][]uint8
+	}{
+		{1, [][]uint8{{a, b, c}, {d, e, f}}},
+		{2, [][]uint8{{c, b, a}, {f, e, d}}},
+		{3, [][]uint8{{f, e, d}, {c, b, a}}},
+		{4, [][]uint8{{d, e, f}, {a, b, c}}},
+		{5, [][]uint8{{a, d}, {b, e}, {c, f}}},
+		{6, [][]uint8{{d, a}, {e, b}, {f, c}}},
+		{7, [][]uint8{{f, c}, {e, b}, {d, a}}},
+		{8, [][]uint8{{c, f}, {b, e}, {a, d}}},
+	}
+
+	sources := map[string]func() image.Image{
+		"rgba": func() image.Image {
+			img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+			for y, row := range src {
+				for x, r := range row {
+					img.Set(x, y, color.RGBA{r, 0, 0, 255})
+				}
+			}
+			return img
+		},
+		"offset nrgba": func() image.Image {
+			img := image.NewNRGBA(image.Rect(5, 7, 8, 9))
+			for y, row := range src {
+				for x, r := range row {
+					img.Set(5+x, 7+y, color.NRGBA{r, 0, 0, 255})
+				}
+			}
+			return img
+		},
+	}
+
+	for name, newImage := range sources {
+		for _, tt := range tests {
+			img := orient(newImage(), tt.orientation)
+
+			b := img.Bounds()
+			got := make([][]uint8, b.Dy())
+			for y := range got {
+				got[y] = make([]uint8, b.Dx())
+				for x := range got[y] {
+					r, _, _, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+					got[y][x] = uint8(r >> 8)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: orient(%d) = %v, want %v", name, tt.orientation, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestExceeds(t *testing.T) {
+	tests := []struct {
+		size, max int
+		want      bool
+	}{
+		{100, 8000, false},
+		{8000, 8000, false},
+		{8001, 8000, true},
+		{100000, 0, false},
+	}
+
+	for _, tt := range tests {
+		if got := exceeds(tt.size, tt.max); got != tt.want {
+			t.Errorf("exceeds(%d, %d) = %v, want %v", tt.size, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestStripJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	picture := buf.Bytes()[2:]
+
+	// an APP1 segment of EXIF data holding nothing but its header
+	exif := []byte("\xff\xe1\x00\x16Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00")
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(append([][]byte{[]byte("\xff\xd8")}, parts...), nil)
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		stripped bool
+	}{
+		{"without metadata", join(picture), true},
+		{"with EXIF", join(exif, picture), true},
+		// the decoder skips the stray byte, so the file would be stored as it is
+		{"stray byte after EXIF", join(exif, []byte{0x00}, picture), false},
+		{"truncated segment", join(exif[:10]), false},
+	}
+
+	for _, tt := range tests {
+		if _, err := jpeg.DecodeConfig(bytes.NewReader(tt.data)); err != nil && tt.stripped {
+			t.Fatalf("%s: test image does not decode: %v", tt.name, err)
+		}
+
+		got, _ := stripJPEG(tt.data)
+		if (got != nil) != tt.stripped {
+			t.Errorf("%s: stripJPEG() = %v, want stripped %v", tt.name, got != nil, tt.stripped)
+		}
+		if bytes.Contains(got, []byte("Exif")) {
+			t.Errorf("%s: stripJPEG() kept the EXIF data", tt.name)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package upload
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+var (
+	ErrImageTooLarge   = errors.New("image file is too large")
+	ErrImageDimensions = errors.New("image dimensions are too large")
+	ErrImageExt        = errors.New("image extension does not match its content")
+)
+
+// imageExts are the extensions each sniffed content type may be saved under
+var imageExts = map[string][]string{
+	"image/jpeg": {".jpg", ".jpeg"},
+	"image/png":  {".png"},
+	"image/gif":  {".gif"},
+}
+
+// GetMaxUploadSize get the largest request body an image upload may have,
+// leaving room for the multipart headers around the file
+func GetMaxUploadSize() int64 {
+	return int64(setting.AppSetting.ImageMaxSize) + 1<<20
+}
+
+// ReadImage reads an uploaded image, giving up as soon as it passes the size
+// limit, and returns it cleaned by CleanImage
+func ReadImage(r io.Reader, ext string) ([]byte, error) {
+	max := int64(setting.AppSetting.ImageMaxSize)
+	data, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, ErrImageTooLarge
+	}
+
+	return CleanImage(data, ext)
+}
+
+// CleanImage checks that data is a whole image of an allowed type matching the
+// extension and within the configured dimensions, and returns it without
+// EXIF, GPS or other embedded metadata
+func CleanImage(data []byte, ext string) ([]byte, error) {
+	if len(data) > setting.AppSetting.ImageMaxSize {
+		return nil, ErrImageTooLarge
+	}
+	if !CheckImageExt(ext) {
+		return nil, ErrImageExt
+	}
+
+	// the content decides the type, the extension only has to agree with it
+	contentType := http.DetectContentType(data)
+	matched := false
+	for _, e := range imageExts[contentType] {
+		if strings.EqualFold(e, ext) {
+			matched = true
+		}
+	}
+	if !matched {
+		if _, ok := imageExts[contentType]; !ok {
+			return nil, ErrNotImage
+		}
+		return nil, ErrImageExt
+	}
+
+	// the header is checked before decoding, which allocates for every pixel
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+	if exceeds(config.Width, setting.ImageSetting.MaxWidth) || exceeds(config.Height, setting.ImageSetting.MaxHeight) {
+		return nil, ErrImageDimensions
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+
+	switch contentType {
+	case "image/jpeg":
+		stripped, orientation := stripJPEG(data)
+		if stripped != nil && orientation <= 1 {
+			return stripped, nil
+		}
+
+		// the orientation goes with the EXIF data, so it is applied to the pixels,
+		// and a file that cannot be stripped is written again from them
+		var buf bytes.Buffer
+		err := jpeg.Encode(&buf, orient(img, orientation), &jpeg.Options{Quality: getQuality()})
+		return buf.Bytes(), err
+	case "image/png":
+		if stripped := stripPNG(data); stripped != nil {
+			return stripped, nil
+		}
+
+		var buf bytes.Buffer
+		err := png.Encode(&buf, img)
+		return buf.Bytes(), err
+	}
+
+	return data, nil
+}
+
+// exceeds checks a dimension against its limit, 0 meaning there is none
+func exceeds(size, max int) bool {
+	return max > 0 && size > max
+}
@@ -0,0 +1,363 @@
+package upload
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/draw"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/file"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+var ErrNotImage = errors.New("not a supported image")
+
+// ORIGINAL_MARKER_EXT ends the name of the marker left in the cache for an
+// image served as it is at a width
+const ORIGINAL_MARKER_EXT = ".original"
+
+var (
+	renderMu    sync.Mutex
+	renders     = make(map[string]*pendingRender)
+	renderOnce  sync.Once
+	renderSlots chan struct{}
+)
+
+// pendingRender is a resized copy being made, done is closed once it is
+type pendingRender struct {
+	done    chan struct{}
+	resized bool
+	err     error
+}
+
+// Variant is a resized copy made of every uploaded image
+type Variant struct {
+	Name  string
+	Width int
+}
+
+// ImageVariant is a variant of one image as handed to clients
+type ImageVariant struct {
+	Url    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// GetVariants get the configured variants, narrowest first
+func GetVariants() []Variant {
+	var variants []Variant
+	for _, v := range setting.ImageSetting.Variants {
+		i := strings.LastIndex(v, ":")
+		if i < 0 {
+			logging.Warn("invalid image variant: ", v)
+			continue
+		}
+		width, err := strconv.Atoi(strings.TrimSpace(v[i+1:]))
+		if err != nil || width <= 0 {
+			logging.Warn("invalid image variant: ", v)
+			continue
+		}
+
+		variants = append(variants, Variant{Name: strings.TrimSpace(v[:i]), Width: width})
+	}
+
+	sort.SliceStable(variants, func(i, j int) bool {
+		return variants[i].Width < variants[j].Width
+	})
+	return variants
+}
+
+// IsVariantWidth checks if a width is that of a configured variant, the only
+// sizes images are resized to on request
+func IsVariantWidth(width int) bool {
+	for _, v := range GetVariants() {
+		if v.Width == width {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetCacheFullPath get the full save path of resized images
+func GetCacheFullPath() string {
+	return setting.AppSetting.RuntimeRootPath + setting.ImageSetting.CachePath
+}
+
+// GetVariantUrl get the access path of an image resized to the width. Resized
+// copies are always served by the application, wherever the original is stored
+func GetVariantUrl(name string, width int) string {
+	return setting.AppSetting.PrefixUrl + "/" + GetImagePath() + name + "?w=" + strconv.Itoa(width)
+}
+
+// GetVariantFile get the path of an uploaded image resized to the width. The
+// copy is made on first use and again whenever the original is newer. It
+// returns "" for images that are not resized, which are served as they are.
+// That outcome is cached too, as an empty marker file in place of the copy
+func GetVariantFile(name string, width int) (string, error) {
+	info, err := storage.Images.Stat(name)
+	if err != nil {
+		return "", err
+	}
+
+	ext := path.Ext(info.Name)
+	dst := getVariantPath(info.Name, ext, width)
+
+	if cached, err := os.Stat(dst); err == nil && !cached.ModTime().Before(info.ModTime) {
+		return dst, nil
+	}
+	if marker, err := os.Stat(dst + ORIGINAL_MARKER_EXT); err == nil && !marker.ModTime().Before(info.ModTime) {
+		return "", nil
+	}
+
+	resized, err := render(name, dst, width)
+	if err != nil {
+		return "", err
+	}
+	if !resized {
+		return "", nil
+	}
+
+	return dst, nil
+}
+
+// RemoveVariants removes the resized copies of an image
+func RemoveVariants(name string) {
+	clean := path.Clean("/" + name)[1:]
+	ext := path.Ext(clean)
+	for _, v := range GetVariants() {
+		dst := getVariantPath(clean, ext, v.Width)
+		os.Remove(dst)
+		os.Remove(dst + ORIGINAL_MARKER_EXT)
+	}
+}
+
+// MakeVariants makes every variant of an uploaded image and returns them by name
+func MakeVariants(name string) (map[string]ImageVariant, error) {
+	data, err := readImage(name)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotImage
+	}
+
+	animated := isAnimated(data)
+
+	variants := make(map[string]ImageVariant)
+	for _, v := range GetVariants() {
+		if _, err := GetVariantFile(name, v.Width); err != nil {
+			return nil, err
+		}
+
+		width, height := config.Width, config.Height
+		if !animated {
+			width, height = scaledSize(config.Width, config.Height, v.Width)
+		}
+		variants[v.Name] = ImageVariant{
+			Url:    GetVariantUrl(name, v.Width),
+			Width:  width,
+			Height: height,
+		}
+	}
+
+	return variants, nil
+}
+
+// GetSrcset formats variants for the srcset attribute of an img element
+func GetSrcset(variants map[string]ImageVariant) string {
+	list := make([]ImageVariant, 0, len(variants))
+	seen := make(map[int]bool)
+	for _, v := range variants {
+		if !seen[v.Width] {
+			seen[v.Width] = true
+			list = append(list, v)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Width < list[j].Width
+	})
+
+	candidates := make([]string, len(list))
+	for i, v := range list {
+		candidates[i] = v.Url + " " + strconv.Itoa(v.Width) + "w"
+	}
+
+	return strings.Join(candidates, ", ")
+}
+
+// scaledSize is the size of an image scaled down to at most the width
+func scaledSize(width, height, max int) (int, int) {
+	if width <= max || width == 0 {
+		return width, height
+	}
+
+	h := height * max / width
+	if h < 1 {
+		h = 1
+	}
+	return max, h
+}
+
+// getVariantPath is where the copy of an image resized to the width is cached
+func getVariantPath(name, ext string, width int) string {
+	return GetCacheFullPath() + strings.TrimSuffix(name, ext) + "-w" + strconv.Itoa(width) + ext
+}
+
+// readImage reads a stored image, which uploads keep small enough to hold in memory
+func readImage(name string) ([]byte, error) {
+	r, err := storage.Images.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+// render resizes an image into dst. Requests for a copy already being made
+// wait for it rather than make it again, and at most ImageSetting.Resizers
+// copies are made at once
+func render(name, dst string, width int) (bool, error) {
+	renderMu.Lock()
+	if r, ok := renders[dst]; ok {
+		renderMu.Unlock()
+		<-r.done
+		return r.resized, r.err
+	}
+	r := &pendingRender{done: make(chan struct{})}
+	renders[dst] = r
+	renderMu.Unlock()
+
+	renderOnce.Do(func() {
+		n := setting.ImageSetting.Resizers
+		if n <= 0 {
+			n = runtime.NumCPU()
+		}
+		renderSlots = make(chan struct{}, n)
+	})
+
+	renderSlots <- struct{}{}
+	data, err := readImage(name)
+	if err == nil {
+		r.resized, r.err = resize(data, dst, width)
+	} else {
+		r.err = err
+	}
+	if r.err == nil && !r.resized {
+		r.err = markOriginal(dst)
+	}
+	<-renderSlots
+
+	renderMu.Lock()
+	delete(renders, dst)
+	renderMu.Unlock()
+	close(r.done)
+
+	return r.resized, r.err
+}
+
+// resize writes an image scaled to the width into dst, in the format of the
+// original. It reports false, writing nothing, if the image is not wider, is
+// an animated GIF, which would lose all but its first frame, or is larger
+// than uploads may be. Images stored before the limits or put back from a
+// backup have not been checked against them
+func resize(data []byte, dst string, width int) (bool, error) {
+	// the header alone tells whether there is anything to do
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return false, ErrNotImage
+	}
+	if exceeds(config.Width, setting.ImageSetting.MaxWidth) || exceeds(config.Height, setting.ImageSetting.MaxHeight) {
+		return false, nil
+	}
+	w, h := scaledSize(config.Width, config.Height, width)
+	if w == config.Width || isAnimated(data) {
+		return false, nil
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return false, ErrNotImage
+	}
+	bounds := img.Bounds()
+
+	scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Over, nil)
+
+	if err := file.IsNotExistMkDir(filepath.Dir(dst)); err != nil {
+		return false, err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), ".resize-")
+	if err != nil {
+		return false, err
+	}
+
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(tmp, scaled, &jpeg.Options{Quality: getQuality()})
+	case "gif":
+		err = gif.Encode(tmp, scaled, nil)
+	default:
+		err = png.Encode(tmp, scaled)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+
+	// renaming makes the copy appear whole to concurrent readers
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+
+	return true, nil
+}
+
+// markOriginal leaves the marker of an image served as it is at a width
+func markOriginal(dst string) error {
+	if err := file.IsNotExistMkDir(filepath.Dir(dst)); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(dst+ORIGINAL_MARKER_EXT, nil, 0644)
+}
+
+// isAnimated checks if an image is a GIF of more than one frame
+func isAnimated(data []byte) bool {
+	if !bytes.HasPrefix(data, []byte("GIF8")) {
+		return false
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	return err == nil && len(g.Image) > 1
+}
+
+func getQuality() int {
+	quality := setting.ImageSetting.Quality
+	if quality < 1 || quality > 100 {
+		return jpeg.DefaultQuality
+	}
+
+	return quality
+}
@@ -0,0 +1,112 @@
+package upload
+
+import (
+	"bytes"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+func TestIsVariantWidth(t *testing.T) {
+	setting.ImageSetting.Variants = []string{"thumbnail:150", "medium:640", "large:1280"}
+
+	tests := []struct {
+		width int
+		want  bool
+	}{
+		{150, true},
+		{640, true},
+		{1280, true},
+		{151, false},
+		{0, false},
+		{-150, false},
+		{100000, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsVariantWidth(tt.width); got != tt.want {
+			t.Errorf("IsVariantWidth(%d) = %v, want %v", tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestIsAnimated(t *testing.T) {
+	encode := func(frames int) []byte {
+		g := &gif.GIF{}
+		for i := 0; i < frames; i++ {
+			g.Image = append(g.Image, image.NewPaletted(image.Rect(0, 0, 4, 4), palette.Plan9))
+			g.Delay = append(g.Delay, 10)
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, g); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"single frame", encode(1), false},
+		{"animated", encode(3), true},
+		{"not a gif", []byte("\x89PNG\r\n\x1a\n"), false},
+		{"truncated", encode(3)[:20], false},
+	}
+
+	for _, tt := range tests {
+		if got := isAnimated(tt.data); got != tt.want {
+			t.Errorf("%s: isAnimated() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resize-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(w, h int) {
+		setting.ImageSetting.MaxWidth, setting.ImageSetting.MaxHeight = w, h
+	}(setting.ImageSetting.MaxWidth, setting.ImageSetting.MaxHeight)
+
+	tests := []struct {
+		name     string
+		maxWidth int
+		width    int
+		want     bool
+	}{
+		{"narrower", 0, 20, true},
+		{"not wider", 0, 40, false},
+		{"over the upload limit", 30, 20, false},
+	}
+
+	for i, tt := range tests {
+		setting.ImageSetting.MaxWidth, setting.ImageSetting.MaxHeight = tt.maxWidth, 0
+		dst := filepath.Join(dir, strconv.Itoa(i)+".png")
+
+		resized, err := resize(buf.Bytes(), dst, tt.width)
+		if err != nil || resized != tt.want {
+			t.Errorf("%s: resize() = %v, %v, want %v", tt.name, resized, err, tt.want)
+		}
+		if _, err := os.Stat(dst); (err == nil) != tt.want {
+			t.Errorf("%s: copy written = %v, want %v", tt.name, err == nil, tt.want)
+		}
+	}
+}
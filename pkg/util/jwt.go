@@ -6,22 +6,35 @@ import (
 	"github.com/dgrijalva/jwt-go"
 )
 
+// ADMIN_ROLE is the role of accounts allowed to manage the whole site
+const ADMIN_ROLE = "admin"
+
 var jwtSecret []byte
 
 type Claims struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Role     string `json:"role,omitempty"`
+	// Name is the user name in clear, which uploads are credited to
+	Name string `json:"name,omitempty"`
 	jwt.StandardClaims
 }
 
-// GenerateToken generate tokens used for auth
-func GenerateToken(username, password string) (string, error) {
+// IsAdmin checks if the token was issued to an administrator
+func (c *Claims) IsAdmin() bool {
+	return c.Role == ADMIN_ROLE
+}
+
+// GenerateToken generate tokens used for auth, carrying the role of the account
+func GenerateToken(username, password, role string) (string, error) {
 	nowTime := time.Now()
 	expireTime := nowTime.Add(3 * time.Hour)
 
 	claims := Claims{
 		EncodeMD5(username),
 		EncodeMD5(password),
+		role,
+		username,
 		jwt.StandardClaims{
 			ExpiresAt: expireTime.Unix(),
 			Issuer:    "gin-blog",
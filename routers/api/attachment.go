@@ -0,0 +1,55 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/service/attachment_service"
+)
+
+// @Summary Download an attachment of a published article
+// @Produce  octet-stream
+// @Param id path int true "ID"
+// @Success 200 {string} string
+// @Failure 404
+// @Router /attachments/{id} [get]
+func DownloadAttachment(c *gin.Context) {
+	id := com.StrTo(c.Param("id")).MustInt()
+	if id < 1 {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	attachmentService := attachment_service.Attachment{ID: id}
+	attachment, err := attachmentService.GetPublished()
+	if err != nil {
+		logging.Warn(err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if attachment == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	// a resumed download or a HEAD request is not another download
+	rng := c.GetHeader("Range")
+	if c.Request.Method == http.MethodGet && (rng == "" || strings.HasPrefix(rng, "bytes=0-")) {
+		if err := attachmentService.CountDownload(); err != nil {
+			logging.Warn(err)
+		}
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": attachment.OriginalName,
+	}))
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	serveStored(c, storage.Attachments, attachment.Name)
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/export"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+// @Summary Download an export file through a signed URL
+// @Produce  octet-stream
+// @Param name path string true "File name"
+// @Param expires query int true "Expiry as a Unix time"
+// @Param signature query string true "Signature"
+// @Success 200 {string} string
+// @Failure 403 {object} app.Response
+// @Router /export/{name} [get]
+func DownloadExport(c *gin.Context) {
+	appG := app.Gin{C: c}
+	name := c.Param("name")
+
+	if !export.CheckSignature(name, c.Query("expires"), c.Query("signature")) {
+		appG.Response(http.StatusForbidden, e.ERROR_EXPORT_DOWNLOAD_EXPIRED, nil)
+		return
+	}
+
+	if path.Base(name) != name || !storage.Exists(storage.Exports, name) {
+		appG.Response(http.StatusNotFound, e.ERROR_NOT_EXIST_EXPORT_FILE, nil)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+	serveStored(c, storage.Exports, name)
+}
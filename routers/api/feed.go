@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/feed"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/service/feed_service"
+	"github.com/EDDYCJY/go-gin-example/service/tag_service"
+)
+
+// @Summary Get the site feed
+// @Produce  xml
+// @Param format path string true "rss, atom or json, or rss.xml, atom.xml or feed.json"
+// @Success 200 {string} string
+// @Failure 500 {object} app.Response
+// @Router /feed/{format} [get]
+func GetFeed(c *gin.Context) {
+	renderFeed(c, &feed_service.Feed{Format: feed.ParseFormat(c.Param("format"))})
+}
+
+// @Summary Get the feed of a tag
+// @Produce  xml
+// @Param id path int true "TagID"
+// @Param format path string true "rss, atom or json, or rss.xml, atom.xml or feed.json"
+// @Success 200 {string} string
+// @Failure 500 {object} app.Response
+// @Router /tags/{id}/feed/{format} [get]
+func GetTagFeed(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid := validation.Validation{}
+	valid.Min(id, 1, "id")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	tagService := tag_service.Tag{ID: id}
+	exists, err := tagService.ExistByID()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_EXIST_TAG_FAIL, nil)
+		return
+	}
+	if !exists {
+		appG.Response(http.StatusNotFound, e.ERROR_NOT_EXIST_TAG, nil)
+		return
+	}
+
+	renderFeed(c, &feed_service.Feed{Format: feed.ParseFormat(c.Param("format")), TagID: id})
+}
+
+// @Summary Get the feed of an author
+// @Produce  xml
+// @Param name path string true "CreatedBy"
+// @Param format path string true "rss, atom or json, or rss.xml, atom.xml or feed.json"
+// @Success 200 {string} string
+// @Failure 500 {object} app.Response
+// @Router /authors/{name}/feed/{format} [get]
+func GetAuthorFeed(c *gin.Context) {
+	renderFeed(c, &feed_service.Feed{Format: feed.ParseFormat(c.Param("format")), Author: c.Param("name")})
+}
+
+func renderFeed(c *gin.Context, feedService *feed_service.Feed) {
+	appG := app.Gin{C: c}
+	if feed.ContentType(feedService.Format) == "" {
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	rendered, err := feedService.Render()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_FEED_FAIL, nil)
+		return
+	}
+
+	if appG.CheckNotModified(rendered.ETag, time.Unix(rendered.LastModified, 0)) {
+		return
+	}
+
+	c.Data(http.StatusOK, rendered.ContentType, rendered.Body)
+}
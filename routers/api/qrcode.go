@@ -0,0 +1,18 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+// @Summary Get a generated QR code or article poster
+// @Produce  image/jpeg
+// @Param name path string true "File name"
+// @Success 200 {file} binary
+// @Router /qrcode/{name} [get]
+func GetQrCode(c *gin.Context) {
+	serveStored(c, storage.Posters, strings.TrimPrefix(c.Param("name"), "/"))
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/service/sitemap_service"
+)
+
+// @Summary Get the sitemap or sitemap index
+// @Produce  xml
+// @Success 200 {string} string
+// @Failure 500 {object} app.Response
+// @Router /sitemap.xml [get]
+func GetSitemap(c *gin.Context) {
+	appG := app.Gin{C: c}
+	src, err := sitemap_service.GetIndexFullPath()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_SITEMAP_FAIL, nil)
+		return
+	}
+
+	c.Header("Content-Type", "application/xml; charset=utf-8")
+	c.File(src)
+}
+
+// @Summary Get robots.txt
+// @Produce  plain
+// @Success 200 {string} string
+// @Router /robots.txt [get]
+func GetRobots(c *gin.Context) {
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", sitemap_service.GetRobots())
+}
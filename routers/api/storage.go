@@ -0,0 +1,53 @@
+package api
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+// serveStored writes a stored file to the response. Files on local disk get
+// range and conditional requests answered, others are copied as they come
+func serveStored(c *gin.Context, s storage.Storage, name string) {
+	info, err := s.Stat(name)
+	if err != nil {
+		if !os.IsNotExist(err) && err != storage.ErrInvalidName {
+			logging.Warn(err)
+		}
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	r, err := s.Get(name)
+	if err != nil {
+		logging.Warn(err)
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer r.Close()
+
+	if rs, ok := r.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, info.Name, info.ModTime, rs)
+		return
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(info.Name)); contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+	c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+	if !info.ModTime.IsZero() {
+		c.Header("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	}
+	c.Status(http.StatusOK)
+	if c.Request.Method != http.MethodHead {
+		io.Copy(c.Writer, r)
+	}
+}
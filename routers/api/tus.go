@@ -0,0 +1,364 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/middleware/quota"
+	"github.com/EDDYCJY/go-gin-example/middleware/tus"
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/attachment_service"
+	"github.com/EDDYCJY/go-gin-example/service/tus_service"
+)
+
+var errInvalidMetadata = errors.New("invalid Upload-Metadata")
+
+// @Summary Describe the tus resumable upload support
+// @Success 204
+// @Router /upload/files [options]
+func TusOptions(c *gin.Context) {
+	c.Header("Tus-Version", tus.VERSION)
+	c.Header("Tus-Extension", "creation,expiration,termination")
+	c.Header("Tus-Max-Size", strconv.FormatInt(tus_service.GetMaxSize(), 10))
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Start a resumable upload of an image, or of a file to attach to an article
+// @Param Upload-Length header int true "Size of the file in bytes"
+// @Param Upload-Metadata header string false "filename, alt_text, caption and, for attachments, article_id, base64 encoded"
+// @Success 201
+// @Failure 400 {object} app.Response
+// @Failure 413 {object} app.Response
+// @Router /upload/files [post]
+func CreateTusUpload(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	metadata, err := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	valid := validation.Validation{}
+	valid.MaxSize(metadata["alt_text"], 255, "alt_text")
+	valid.MaxSize(metadata["caption"], 255, "caption")
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	// the unfinished uploads of the account count against its quota too, and
+	// the room is held until this one is created and counts among them
+	user := quota.GetUser(c)
+	pending, err := tus_service.GetPendingSize(user.Owner)
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_UPLOAD_USAGE_FAIL, nil)
+		return
+	}
+	reservation := reserveQuota(appG, user, pending+length)
+	if reservation == nil {
+		return
+	}
+
+	u, err := tus_service.Create(length, metadata, user)
+	reservation.Release()
+	if err != nil {
+		respondTusError(appG, err)
+		return
+	}
+	if !u.IsImage() && !checkTusArticle(appG, u) {
+		return
+	}
+
+	c.Header("Location", setting.AppSetting.PrefixUrl+"/upload/files/"+u.ID)
+	setTusExpires(c, u)
+	c.Status(http.StatusCreated)
+}
+
+// @Summary Get how much of a resumable upload arrived
+// @Param id path string true "Upload ID"
+// @Success 200
+// @Failure 404
+// @Router /upload/files/{id} [head]
+func HeadTusUpload(c *gin.Context) {
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.Length, 10))
+	if len(u.Metadata) > 0 {
+		c.Header("Upload-Metadata", formatTusMetadata(u.Metadata))
+	}
+	setTusExpires(c, u)
+	c.Status(http.StatusOK)
+}
+
+// @Summary Send the next part of a resumable upload, storing the file once the last arrives
+// @Param id path string true "Upload ID"
+// @Param Upload-Offset header int true "Offset the part starts at"
+// @Success 204
+// @Failure 409
+// @Failure 400 {object} app.Response
+// @Router /upload/files/{id} [patch]
+func PatchTusUpload(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.Status(http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	if c.Request.ContentLength > u.Length-offset {
+		c.Status(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	_, err = u.Write(offset, c.Request.Body)
+	switch err {
+	case nil:
+	case tus_service.ErrOffsetMismatch, tus_service.ErrFinished:
+		c.Status(http.StatusConflict)
+		return
+	case tus_service.ErrLocked:
+		c.Status(http.StatusLocked)
+		return
+	default:
+		// what arrived is kept, so the client resumes from the offset it finds with HEAD
+		logging.Warn(err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	if u.Offset == u.Length {
+		// other uploads may have filled the quota since this one was created
+		reservation := reserveQuota(appG, quota.GetUser(c), u.Length)
+		if reservation == nil {
+			if err := u.Terminate(); err != nil {
+				logging.Warn(err)
+			}
+			return
+		}
+
+		err = u.Finish()
+		reservation.Release()
+		if err != nil {
+			switch err {
+			case tus_service.ErrLocked, tus_service.ErrFinished:
+				c.Status(http.StatusConflict)
+			case upload.ErrImageTooLarge, upload.ErrImageDimensions, upload.ErrNotImage, upload.ErrImageExt,
+				upload.ErrFileType, upload.ErrFileTooLarge:
+				respondTusError(appG, err)
+			default:
+				logging.Warn(err)
+				appG.Response(http.StatusInternalServerError, e.ERROR_UPLOAD_SAVE_FILE_FAIL, nil)
+			}
+			return
+		}
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	setTusExpires(c, u)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Cancel a resumable upload
+// @Param id path string true "Upload ID"
+// @Success 204
+// @Failure 404
+// @Router /upload/files/{id} [delete]
+func DeleteTusUpload(c *gin.Context) {
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+
+	err := u.Terminate()
+	switch err {
+	case nil:
+		c.Status(http.StatusNoContent)
+	case tus_service.ErrLocked:
+		c.Status(http.StatusLocked)
+	default:
+		logging.Warn(err)
+		c.Status(http.StatusInternalServerError)
+	}
+}
+
+// @Summary Get a resumable upload, with the stored image or attachment once it is finished
+// @Produce  json
+// @Param id path string true "Upload ID"
+// @Success 200 {object} app.Response
+// @Failure 404 {object} app.Response
+// @Router /upload/files/{id} [get]
+func GetTusUpload(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	u, err := tus_service.Get(c.Param("id"))
+	if err == tus_service.ErrNotFound || (err == nil && u.Owner != quota.GetUser(c).Owner) {
+		appG.Response(http.StatusNotFound, e.ERROR_NOT_EXIST_UPLOAD, nil)
+		return
+	}
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR, nil)
+		return
+	}
+
+	data := map[string]interface{}{}
+	if u.MediaID > 0 {
+		media, err := models.GetMedia(u.MediaID)
+		if err != nil {
+			logging.Warn(err)
+			appG.Response(http.StatusInternalServerError, e.ERROR_GET_MEDIA_FAIL, nil)
+			return
+		}
+		if media.ID > 0 {
+			data = getImageData(media)
+		}
+	}
+	if u.AttachmentID > 0 {
+		attachment, err := models.GetAttachment(u.AttachmentID)
+		if err != nil {
+			logging.Warn(err)
+			appG.Response(http.StatusInternalServerError, e.ERROR_GET_ATTACHMENTS_FAIL, nil)
+			return
+		}
+		if attachment.ID > 0 {
+			data["attachment"] = attachment
+			data["url"] = attachment_service.GetAttachmentUrl(attachment.ID)
+		}
+	}
+	data["upload"] = u
+
+	appG.Response(http.StatusOK, e.SUCCESS, data)
+}
+
+// getTusUpload gets the upload named in the path, answering when there is none.
+// The uploads of other accounts are not there as far as the caller is concerned
+func getTusUpload(c *gin.Context) (*tus_service.Upload, bool) {
+	u, err := tus_service.Get(c.Param("id"))
+	if err == tus_service.ErrNotFound || (err == nil && u.Owner != quota.GetUser(c).Owner) {
+		c.Status(http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		logging.Warn(err)
+		c.Status(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return u, true
+}
+
+// respondTusError answers an upload refused by the policy of its file type
+func respondTusError(appG app.Gin, err error) {
+	switch err {
+	case upload.ErrFileType:
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_FILE_TYPE, nil)
+	case upload.ErrFileTooLarge:
+		appG.Response(http.StatusRequestEntityTooLarge, e.ERROR_UPLOAD_FILE_TOO_LARGE, nil)
+	case tus_service.ErrNoArticle:
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+	default:
+		respondImageError(appG, err)
+	}
+}
+
+// checkTusArticle terminates an attachment upload and answers, returning false,
+// unless the article it names exists
+func checkTusArticle(appG app.Gin, u *tus_service.Upload) bool {
+	articleService := article_service.Article{ID: u.GetArticleID()}
+	exists, err := articleService.ExistByID()
+	if err == nil && exists {
+		return true
+	}
+
+	if terr := u.Terminate(); terr != nil {
+		logging.Warn(terr)
+	}
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_ARTICLE_FAIL, nil)
+		return false
+	}
+	appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ARTICLE, nil)
+	return false
+}
+
+func setTusExpires(c *gin.Context, u *tus_service.Upload) {
+	c.Header("Upload-Expires", time.Unix(u.ExpiresOn, 0).UTC().Format(http.TimeFormat))
+}
+
+// parseTusMetadata decodes Upload-Metadata, comma separated pairs of a key
+// and a base64 value that may be left out
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, errInvalidMetadata
+		}
+
+		value := ""
+		if len(fields) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, errInvalidMetadata
+			}
+			value = string(decoded)
+		}
+		metadata[fields[0]] = value
+	}
+
+	return metadata, nil
+}
+
+func formatTusMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		if value == "" {
+			pairs = append(pairs, key)
+			continue
+		}
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
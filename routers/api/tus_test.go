@@ -0,0 +1,39 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTusMetadata(t *testing.T) {
+	tests := []struct {
+		header string
+		want   map[string]string
+		hasErr bool
+	}{
+		{"", map[string]string{}, false},
+		{"filename YS5wbmc=", map[string]string{"filename": "a.png"}, false},
+		{"filename YS5wbmc=, article_id Mw==,is_confidential", map[string]string{"filename": "a.png", "article_id": "3", "is_confidential": ""}, false},
+		{"filename not-base64!", nil, true},
+		{"filename YS5wbmc= extra", nil, true},
+		{"filename YS5wbmc=,,", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTusMetadata(tt.header)
+		if (err != nil) != tt.hasErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTusMetadata(%q) = %v, %v, want %v", tt.header, got, err, tt.want)
+		}
+		if err == nil && !reflect.DeepEqual(mustParse(t, formatTusMetadata(got)), got) {
+			t.Errorf("formatTusMetadata(%v) does not parse back", got)
+		}
+	}
+}
+
+func mustParse(t *testing.T, header string) map[string]string {
+	metadata, err := parseTusMetadata(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata
+}
@@ -2,60 +2,185 @@ package api
 
 import (
 	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
 
+	"github.com/astaxie/beego/validation"
 	"github.com/gin-gonic/gin"
 
+	"github.com/EDDYCJY/go-gin-example/middleware/quota"
+	"github.com/EDDYCJY/go-gin-example/models"
 	"github.com/EDDYCJY/go-gin-example/pkg/app"
 	"github.com/EDDYCJY/go-gin-example/pkg/e"
 	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
 	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/service/media_service"
+	"github.com/EDDYCJY/go-gin-example/service/quota_service"
 )
 
 // @Summary Import Image
 // @Produce  json
 // @Param image formData file true "Image File"
+// @Param alt_text formData string false "Alt text"
+// @Param caption formData string false "Caption"
 // @Success 200 {object} app.Response
+// @Failure 403 {object} app.Response
+// @Failure 429 {object} app.Response
 // @Failure 500 {object} app.Response
-// @Router /api/v1/tags/import [post]
+// @Router /upload [post]
 func UploadImage(c *gin.Context) {
 	appG := app.Gin{C: c}
+	limit := app.LimitBody(c, upload.GetMaxUploadSize())
 	file, image, err := c.Request.FormFile("image")
 	if err != nil {
 		logging.Warn(err)
+		if limit.Exceeded() {
+			appG.Response(http.StatusRequestEntityTooLarge, e.ERROR_UPLOAD_IMAGE_TOO_LARGE, nil)
+			return
+		}
 		appG.Response(http.StatusInternalServerError, e.ERROR, nil)
 		return
 	}
+	defer file.Close()
 
-	if image == nil {
+	valid := validation.Validation{}
+	valid.MaxSize(c.PostForm("alt_text"), 255, "alt_text")
+	valid.MaxSize(c.PostForm("caption"), 255, "caption")
+	if image == nil || valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
 		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
 		return
 	}
 
-	imageName := upload.GetImageName(image.Filename)
-	fullPath := upload.GetImageFullPath()
-	savePath := upload.GetImagePath()
-	src := fullPath + imageName
-
-	if !upload.CheckImageExt(imageName) || !upload.CheckImageSize(file) {
-		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_CHECK_IMAGE_FORMAT, nil)
+	user := quota.GetUser(c)
+	reservation := reserveQuota(appG, user, image.Size)
+	if reservation == nil {
 		return
 	}
+	defer reservation.Release()
 
-	err = upload.CheckImage(fullPath)
+	content, err := upload.ReadImage(file, path.Ext(image.Filename))
 	if err != nil {
-		logging.Warn(err)
-		appG.Response(http.StatusInternalServerError, e.ERROR_UPLOAD_CHECK_IMAGE_FAIL, nil)
+		respondImageError(appG, err)
 		return
 	}
 
-	if err := c.SaveUploadedFile(image, src); err != nil {
+	mediaService := media_service.Media{
+		Data:         content,
+		OriginalName: image.Filename,
+		AltText:      c.PostForm("alt_text"),
+		Caption:      c.PostForm("caption"),
+		Owner:        user.Owner,
+		CreatedBy:    user.Name,
+	}
+	media, duplicate, err := mediaService.Save()
+	if err != nil {
 		logging.Warn(err)
 		appG.Response(http.StatusInternalServerError, e.ERROR_UPLOAD_SAVE_IMAGE_FAIL, nil)
 		return
 	}
 
-	appG.Response(http.StatusOK, e.SUCCESS, map[string]string{
+	data := getImageData(media)
+	data["duplicate"] = duplicate
+	appG.Response(http.StatusOK, e.SUCCESS, data)
+}
+
+// reserveQuota holds size bytes of the upload quota of the user while an
+// upload is saved. It writes the error response and returns nil when they do
+// not fit
+func reserveQuota(appG app.Gin, user *quota_service.User, size int64) *quota_service.Reservation {
+	reservation, err := user.Reserve(size)
+	if err != nil {
+		respondQuotaError(appG, err)
+		return nil
+	}
+
+	return reservation
+}
+
+func respondQuotaError(appG app.Gin, err error) {
+	if err == quota_service.ErrQuotaExceeded {
+		appG.Response(http.StatusForbidden, e.ERROR_UPLOAD_QUOTA_EXCEEDED, nil)
+		return
+	}
+
+	logging.Warn(err)
+	appG.Response(http.StatusInternalServerError, e.ERROR_GET_UPLOAD_USAGE_FAIL, nil)
+}
+
+// respondImageError answers an upload refused by upload.ReadImage
+func respondImageError(appG app.Gin, err error) {
+	switch err {
+	case upload.ErrImageTooLarge:
+		appG.Response(http.StatusRequestEntityTooLarge, e.ERROR_UPLOAD_IMAGE_TOO_LARGE, nil)
+	case upload.ErrImageDimensions:
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_IMAGE_DIMENSIONS, nil)
+	case upload.ErrNotImage, upload.ErrImageExt:
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_CHECK_IMAGE_FORMAT, nil)
+	default:
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_UPLOAD_CHECK_IMAGE_FAIL, nil)
+	}
+}
+
+// getImageData describes a stored image the way uploads answer
+func getImageData(media *models.Media) map[string]interface{} {
+	imageName := media.Name
+	data := map[string]interface{}{
 		"image_url":      upload.GetImageFullUrl(imageName),
-		"image_save_url": savePath + imageName,
-	})
+		"image_save_url": upload.GetImagePath() + imageName,
+		"media":          media,
+	}
+
+	variants, err := upload.MakeVariants(imageName)
+	if err != nil {
+		logging.Warn(err)
+	} else {
+		data["variants"] = variants
+		data["srcset"] = upload.GetSrcset(variants)
+	}
+
+	return data
+}
+
+// @Summary Get an uploaded image, resized when w is given
+// @Produce  image/jpeg,image/png,image/gif
+// @Param name path string true "Image name"
+// @Param w query int false "Width of one of the configured variants"
+// @Success 200 {file} binary
+// @Router /upload/images/{name} [get]
+func GetImage(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("name"), "/")
+
+	if arg := c.Query("w"); arg != "" {
+		width, err := strconv.Atoi(arg)
+		if err != nil || !upload.IsVariantWidth(width) {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+
+		src, err := upload.GetVariantFile(name, width)
+		if os.IsNotExist(err) || err == storage.ErrInvalidName {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		if err == upload.ErrNotImage {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logging.Warn(err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		if src != "" {
+			c.File(src)
+			return
+		}
+	}
+
+	serveStored(c, storage.Images, name)
 }
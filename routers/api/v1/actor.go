@@ -0,0 +1,23 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/middleware/jwt"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+)
+
+// getActor identifies the reader behind a request: the signed-in user, or an anonymous
+// fingerprint supplied by the client and falling back to the IP and user agent
+func getActor(c *gin.Context) string {
+	if claims, ok := jwt.GetClaims(c); ok {
+		return "user:" + claims.Username
+	}
+
+	fingerprint := c.GetHeader("X-Fingerprint")
+	if fingerprint == "" {
+		fingerprint = c.ClientIP() + "|" + c.Request.UserAgent()
+	}
+
+	return "anon:" + util.EncodeMD5(fingerprint)
+}
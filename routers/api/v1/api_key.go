@@ -0,0 +1,140 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/middleware/jwt"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/service/api_key_service"
+)
+
+// @Summary Get the API keys of the signed in account
+// @Produce  json
+// @Success 200 {object} app.Response
+// @Failure 401 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/api-keys [get]
+func GetApiKeys(c *gin.Context) {
+	appG := app.Gin{C: c}
+	username, ok := getApiKeyAccount(&appG)
+	if !ok {
+		return
+	}
+
+	apiKeyService := api_key_service.ApiKey{Username: username}
+	keys, err := apiKeyService.GetAll()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_API_KEYS_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": keys,
+	})
+}
+
+type AddApiKeyForm struct {
+	Name string `form:"name" valid:"Required;MaxSize(100)"`
+}
+
+// @Summary Create an API key to sign in to WebDAV with, the key only ever returned here
+// @Produce  json
+// @Param name body string true "Name"
+// @Success 200 {object} app.Response
+// @Failure 401 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/api-keys [post]
+func AddApiKey(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form AddApiKeyForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	username, ok := getApiKeyAccount(&appG)
+	if !ok {
+		return
+	}
+
+	apiKeyService := api_key_service.ApiKey{Username: username, Name: form.Name}
+	apiKey, key, err := apiKeyService.Add()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_ADD_API_KEY_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"api_key": apiKey,
+		"key":     key,
+	})
+}
+
+// @Summary Delete an API key of the signed in account
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 401 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/api-keys/{id} [delete]
+func DeleteApiKey(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid.Min(id, 1, "id").Message("ID必须大于0")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	username, ok := getApiKeyAccount(&appG)
+	if !ok {
+		return
+	}
+
+	apiKeyService := api_key_service.ApiKey{ID: id}
+	apiKey, err := apiKeyService.Get()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_API_KEY_FAIL, nil)
+		return
+	}
+	// keys of other accounts are not let on to exist
+	if apiKey.ID == 0 || apiKey.Username != username {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_API_KEY, nil)
+		return
+	}
+
+	if err := apiKeyService.Delete(); err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_API_KEY_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// getApiKeyAccount returns the user name the token was issued to, or writes the
+// error response for tokens issued before they carried it
+func getApiKeyAccount(appG *app.Gin) (string, bool) {
+	claims, ok := jwt.GetClaims(appG.C)
+	if !ok || claims.Name == "" {
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH_CHECK_TOKEN_FAIL, nil)
+		return "", false
+	}
+
+	return claims.Name, true
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/unknwon/com"
@@ -10,10 +11,14 @@ import (
 
 	"github.com/EDDYCJY/go-gin-example/pkg/app"
 	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/export"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 	"github.com/EDDYCJY/go-gin-example/pkg/qrcode"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
 	"github.com/EDDYCJY/go-gin-example/pkg/util"
 	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/job_service"
+	"github.com/EDDYCJY/go-gin-example/service/related_service"
 	"github.com/EDDYCJY/go-gin-example/service/tag_service"
 )
 
@@ -52,6 +57,11 @@ func GetArticle(c *gin.Context) {
 		return
 	}
 
+	relatedService := related_service.Related{ArticleID: id, Viewer: getActor(c)}
+	if err := relatedService.RecordView(); err != nil {
+		logging.Info(err)
+	}
+
 	appG.Response(http.StatusOK, e.SUCCESS, article)
 }
 
@@ -297,7 +307,7 @@ func GenerateArticlePoster(c *gin.Context) {
 	posterName := article_service.GetPosterFlag() + "-" + qrcode.GetQrCodeFileName(qr.URL) + qr.GetQrCodeExt()
 	articlePoster := article_service.NewArticlePoster(posterName, article, qr)
 	articlePosterBgService := article_service.NewArticlePosterBg(
-		"bg.jpg",
+		article_service.GetPosterBgName(),
 		articlePoster,
 		&article_service.Rect{
 			X0: 0,
@@ -322,3 +332,59 @@ func GenerateArticlePoster(c *gin.Context) {
 		"poster_save_url": filePath + posterName,
 	})
 }
+
+// @Summary Export articles
+// @Produce  json
+// @Param format body string false "markdown, xlsx or jsonl, defaults to xlsx"
+// @Param tag_id body int false "TagID"
+// @Param state body int false "State"
+// @Param created_by body string false "CreatedBy"
+// @Param stream body bool false "Write the file into the response instead of queueing a job"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/export [post]
+func ExportArticles(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+
+	format := c.DefaultPostForm("format", article_service.FORMAT_XLSX)
+	if !article_service.CheckExportFormat(format) {
+		valid.SetError("format", "不支持的导出格式")
+	}
+
+	state := -1
+	if arg := c.PostForm("state"); arg != "" {
+		state = com.StrTo(arg).MustInt()
+		valid.Range(state, 0, 1, "state")
+	}
+
+	tagId := -1
+	if arg := c.PostForm("tag_id"); arg != "" {
+		tagId = com.StrTo(arg).MustInt()
+		valid.Min(tagId, 1, "tag_id")
+	}
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	articleService := article_service.Article{
+		TagID:     tagId,
+		State:     state,
+		CreatedBy: c.PostForm("created_by"),
+	}
+
+	if wantsStream(c) {
+		streamExport(appG, export.GetFileName("articles", "", article_service.GetExportExt(format)), func(w io.Writer) error {
+			return articleService.Write(w, format, nil)
+		}, e.ERROR_EXPORT_ARTICLE_FAIL)
+		return
+	}
+
+	job, err := job_service.Enqueue(job_service.KIND_EXPORT_ARTICLES, func(id string, progress export.ProgressFunc) (string, error) {
+		return articleService.Export(format, id, progress)
+	})
+	respondExportJob(appG, job, err)
+}
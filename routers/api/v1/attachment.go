@@ -0,0 +1,211 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/middleware/quota"
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/attachment_service"
+	"github.com/EDDYCJY/go-gin-example/service/quota_service"
+)
+
+// @Summary Get the types of files articles may have attached
+// @Produce  json
+// @Success 200 {object} app.Response
+// @Router /api/v1/attachments/types [get]
+func GetAttachmentTypes(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	appG.Response(http.StatusOK, e.SUCCESS, upload.GetAttachmentPolicies())
+}
+
+// @Summary Get the attachments of an article
+// @Produce  json
+// @Param id path int true "Article ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/{id}/attachments [get]
+func GetArticleAttachments(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid := validation.Validation{}
+	valid.Min(id, 1, "id")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	if !checkAttachmentArticleExist(&appG, id) {
+		return
+	}
+
+	attachmentService := attachment_service.Attachment{ArticleID: id}
+	attachments, err := attachmentService.GetAll()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_ATTACHMENTS_FAIL, nil)
+		return
+	}
+
+	lists := make([]map[string]interface{}, 0, len(attachments))
+	for _, attachment := range attachments {
+		lists = append(lists, getAttachmentData(attachment))
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": lists,
+	})
+}
+
+// @Summary Attach a file to an article
+// @Produce  json
+// @Param article_id formData int true "Article ID"
+// @Param file formData file true "File"
+// @Success 200 {object} app.Response
+// @Failure 400 {object} app.Response
+// @Failure 403 {object} app.Response
+// @Failure 413 {object} app.Response
+// @Failure 429 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/attachments [post]
+func AddAttachment(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	// room is left for the multipart headers around the file
+	limit := app.LimitBody(c, int64(upload.GetMaxAttachmentSize())+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		logging.Warn(err)
+		if limit.Exceeded() {
+			appG.Response(http.StatusRequestEntityTooLarge, e.ERROR_UPLOAD_FILE_TOO_LARGE, nil)
+			return
+		}
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+	defer file.Close()
+
+	id := com.StrTo(c.PostForm("article_id")).MustInt()
+	valid := validation.Validation{}
+	valid.Min(id, 1, "article_id")
+	valid.MaxSize(header.Filename, 255, "file")
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	if !checkAttachmentArticleExist(&appG, id) {
+		return
+	}
+
+	user := quota.GetUser(c)
+	reservation, err := user.Reserve(header.Size)
+	switch err {
+	case nil:
+	case quota_service.ErrQuotaExceeded:
+		appG.Response(http.StatusForbidden, e.ERROR_UPLOAD_QUOTA_EXCEEDED, nil)
+		return
+	default:
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_UPLOAD_USAGE_FAIL, nil)
+		return
+	}
+	defer reservation.Release()
+
+	attachmentService := attachment_service.Attachment{
+		ArticleID:    id,
+		OriginalName: header.Filename,
+		Owner:        user.Owner,
+		CreatedBy:    user.Name,
+	}
+	attachment, err := attachmentService.Add(file)
+	switch err {
+	case nil:
+		appG.Response(http.StatusOK, e.SUCCESS, getAttachmentData(attachment))
+	case upload.ErrFileType:
+		appG.Response(http.StatusBadRequest, e.ERROR_UPLOAD_FILE_TYPE, nil)
+	case upload.ErrFileTooLarge:
+		appG.Response(http.StatusRequestEntityTooLarge, e.ERROR_UPLOAD_FILE_TOO_LARGE, nil)
+	default:
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_UPLOAD_SAVE_FILE_FAIL, nil)
+	}
+}
+
+// @Summary Delete an attachment, as its uploader or an administrator
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 403 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/attachments/{id} [delete]
+func DeleteAttachment(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid.Min(id, 1, "id").Message("ID必须大于0")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	attachmentService := attachment_service.Attachment{ID: id}
+	attachment, err := attachmentService.Get()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_ATTACHMENT_FAIL, nil)
+		return
+	}
+	if attachment.ID == 0 {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ATTACHMENT, nil)
+		return
+	}
+	if !quota.GetUser(c).CanChange(attachment.Owner) {
+		appG.Response(http.StatusForbidden, e.ERROR_AUTH_FORBIDDEN, nil)
+		return
+	}
+
+	if err := attachmentService.Delete(); err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_ATTACHMENT_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// checkAttachmentArticleExist writes the error response and returns false unless the article exists
+func checkAttachmentArticleExist(appG *app.Gin, id int) bool {
+	articleService := article_service.Article{ID: id}
+	exists, err := articleService.ExistByID()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_ARTICLE_FAIL, nil)
+		return false
+	}
+	if !exists {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ARTICLE, nil)
+		return false
+	}
+
+	return true
+}
+
+func getAttachmentData(attachment *models.Attachment) map[string]interface{} {
+	return map[string]interface{}{
+		"attachment": attachment,
+		"url":        attachment_service.GetAttachmentUrl(attachment.ID),
+	}
+}
@@ -0,0 +1,94 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/service/backup_service"
+	"github.com/EDDYCJY/go-gin-example/service/job_service"
+)
+
+type AddBackupForm struct {
+	Accounts bool `form:"accounts"`
+}
+
+type RestoreBackupForm struct {
+	Overwrite bool `form:"overwrite"`
+	Accounts  bool `form:"accounts"`
+}
+
+// @Summary Back up the whole site
+// @Produce  json
+// @Param accounts formData bool false "Also back up the accounts, their passwords in clear"
+// @Success 202 {object} app.Response
+// @Failure 403 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/backups [post]
+func AddBackup(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form AddBackupForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	backup := backup_service.Backup{Accounts: form.Accounts}
+	job, err := job_service.Enqueue(job_service.KIND_BACKUP, backup.Run)
+	respondExportJob(appG, job, err)
+}
+
+// @Summary Restore the site from a backup archive
+// @Produce  json
+// @Param file formData file true "Backup archive"
+// @Param overwrite formData bool false "Replace existing data instead of requiring an empty database"
+// @Param accounts formData bool false "Replace the accounts with those of the archive"
+// @Success 200 {object} app.Response
+// @Failure 403 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/backups/restore [post]
+func RestoreBackup(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form RestoreBackupForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+	defer file.Close()
+
+	restore := backup_service.Restore{Overwrite: form.Overwrite, Accounts: form.Accounts}
+	report, err := restore.Run(file, header.Size)
+	switch {
+	case err == nil:
+		appG.Response(http.StatusOK, e.SUCCESS, report)
+	case err == backup_service.ErrInvalidArchive || errors.Is(err, backup_service.ErrCorruptArchive):
+		logging.Warn(err)
+		appG.Response(http.StatusBadRequest, e.ERROR_BACKUP_INVALID_ARCHIVE, nil)
+	case err == backup_service.ErrUnsupportedVersion:
+		appG.Response(http.StatusBadRequest, e.ERROR_BACKUP_VERSION, nil)
+	case err == backup_service.ErrDatabaseNotEmpty:
+		appG.Response(http.StatusConflict, e.ERROR_RESTORE_NOT_EMPTY, nil)
+	default:
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_RESTORE_BACKUP_FAIL, report)
+	}
+}
@@ -0,0 +1,88 @@
+package v1
+
+import (
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/export"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/service/job_service"
+)
+
+// @Summary Get an export job
+// @Produce  json
+// @Param id path string true "ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/exports/{id} [get]
+func GetExportJob(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	job, err := job_service.Get(c.Param("id"))
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_EXPORT_JOB_FAIL, nil)
+		return
+	}
+	if job == nil {
+		appG.Response(http.StatusNotFound, e.ERROR_NOT_EXIST_EXPORT_JOB, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, exportJobData(job))
+}
+
+// respondExportJob answers an export request with the job it queued
+func respondExportJob(appG app.Gin, job *job_service.Job, err error) {
+	if err == job_service.ErrQueueFull {
+		appG.Response(http.StatusServiceUnavailable, e.ERROR_EXPORT_QUEUE_FULL, nil)
+		return
+	}
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_ENQUEUE_EXPORT_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusAccepted, e.SUCCESS, exportJobData(job))
+}
+
+func exportJobData(job *job_service.Job) map[string]interface{} {
+	data := map[string]interface{}{"job": job}
+	if job.Status == job_service.STATUS_DONE {
+		data["download_url"] = export.GetExcelFullUrl(job.File)
+	}
+
+	return data
+}
+
+// wantsStream reports whether the client asked for the export in the response
+// itself rather than as a background job
+func wantsStream(c *gin.Context) bool {
+	stream, _ := strconv.ParseBool(c.Request.FormValue("stream"))
+	return stream
+}
+
+// streamExport writes an export straight into the response. Once the first
+// bytes are out a failure can only cut the download short, so it is just logged
+func streamExport(appG app.Gin, filename string, write func(w io.Writer) error, errCode int) {
+	header := appG.C.Writer.Header()
+	header.Set("Content-Type", export.GetContentType(path.Ext(filename)))
+	header.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	appG.C.Status(http.StatusOK)
+
+	if err := write(appG.C.Writer); err != nil {
+		logging.Warn(err)
+		if !appG.C.Writer.Written() {
+			header.Del("Content-Type")
+			header.Del("Content-Disposition")
+			appG.Response(http.StatusInternalServerError, errCode, nil)
+		}
+	}
+}
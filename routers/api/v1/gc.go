@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/service/gc_service"
+)
+
+type CollectUploadsForm struct {
+	DryRun bool `form:"dry_run"`
+}
+
+type RestoreQuarantineForm struct {
+	Path string `form:"path" valid:"Required;MaxSize(255)"`
+}
+
+// @Summary Quarantine the uploads no article uses and purge expired quarantined files
+// @Produce  json
+// @Param dry_run formData bool false "Only report what would be quarantined and purged"
+// @Success 200 {object} app.Response
+// @Failure 403 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/gc [post]
+func CollectUploads(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form CollectUploadsForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	collector := gc_service.Collector{DryRun: form.DryRun}
+	report, err := collector.Run()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GC_UPLOADS_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, report)
+}
+
+// @Summary Move a quarantined file back
+// @Produce  json
+// @Param path formData string true "Path in the quarantine, as in the report"
+// @Success 200 {object} app.Response
+// @Failure 403 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/gc/restore [post]
+func RestoreQuarantine(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form RestoreQuarantineForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	err := gc_service.Restore(form.Path)
+	switch {
+	case err == nil:
+		appG.Response(http.StatusOK, e.SUCCESS, nil)
+	case err == gc_service.ErrNotQuarantined:
+		appG.Response(http.StatusNotFound, e.ERROR_NOT_EXIST_QUARANTINE_FILE, nil)
+	default:
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GC_RESTORE_FAIL, nil)
+	}
+}
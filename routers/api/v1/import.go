@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/service/import_service"
+)
+
+type ImportArticlesForm struct {
+	CreatedBy string `form:"created_by" valid:"Required;MaxSize(100)"`
+	DryRun    bool   `form:"dry_run"`
+}
+
+// @Summary Import articles from a zip of Markdown files
+// @Produce  json
+// @Param file formData file true "Zip File"
+// @Param created_by formData string true "Author of posts without one in their front matter"
+// @Param dry_run formData bool false "Only report what would be imported"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/articles/import [post]
+func ImportArticles(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form ImportArticlesForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > setting.ImportSetting.MaxSize {
+		appG.Response(http.StatusBadRequest, e.ERROR_IMPORT_ARTICLE_TOO_LARGE, nil)
+		return
+	}
+
+	source, err := import_service.OpenZip(file, header.Size)
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusBadRequest, e.ERROR_IMPORT_ARTICLE_FORMAT, nil)
+		return
+	}
+
+	importer := import_service.Markdown{
+		Source:    source,
+		CreatedBy: form.CreatedBy,
+		DryRun:    form.DryRun,
+	}
+	report, err := importer.Run()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_IMPORT_ARTICLE_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, report)
+}
@@ -0,0 +1,254 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/middleware/quota"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/media_service"
+)
+
+// @Summary Get multiple media of the library
+// @Produce  json
+// @Param q query string false "Keyword matched against the file name, alt text and caption"
+// @Param created_by query string false "Uploader"
+// @Param content_type query string false "Media type, such as image/png"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/media [get]
+func GetMediaList(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+	keyword := c.Query("q")
+	valid.MaxSize(keyword, 100, "q")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	mediaService := media_service.Media{
+		Keyword:     keyword,
+		CreatedBy:   c.Query("created_by"),
+		ContentType: c.Query("content_type"),
+		PageNum:     util.GetPage(c),
+		PageSize:    setting.AppSetting.PageSize,
+	}
+	media, err := mediaService.GetAll()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_MEDIA_FAIL, nil)
+		return
+	}
+
+	count, err := mediaService.Count()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_COUNT_MEDIA_FAIL, nil)
+		return
+	}
+
+	lists := make([]map[string]interface{}, 0, len(media))
+	for _, item := range media {
+		lists = append(lists, map[string]interface{}{
+			"media": item,
+			"url":   upload.GetImageFullUrl(item.Name),
+		})
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": lists,
+		"total": count,
+	})
+}
+
+// @Summary Get a single media with the articles using it
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/media/{id} [get]
+func GetMedia(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid := validation.Validation{}
+	valid.Min(id, 1, "id")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	mediaService := media_service.Media{ID: id}
+	if !checkMediaExist(&appG, &mediaService) {
+		return
+	}
+
+	media, err := mediaService.Get()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_MEDIA_FAIL, nil)
+		return
+	}
+
+	articles, err := mediaService.GetArticles()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_MEDIA_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"media":    media,
+		"url":      upload.GetImageFullUrl(media.Name),
+		"articles": articles,
+	})
+}
+
+type EditMediaForm struct {
+	ID      int    `form:"id" valid:"Required;Min(1)"`
+	AltText string `form:"alt_text" valid:"MaxSize(255)"`
+	Caption string `form:"caption" valid:"MaxSize(255)"`
+}
+
+// @Summary Update the description of a media, as its uploader or an administrator
+// @Produce  json
+// @Param id path int true "ID"
+// @Param alt_text body string false "AltText"
+// @Param caption body string false "Caption"
+// @Success 200 {object} app.Response
+// @Failure 403 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/media/{id} [put]
+func EditMedia(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form = EditMediaForm{ID: com.StrTo(c.Param("id")).MustInt()}
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	mediaService := media_service.Media{
+		ID:      form.ID,
+		AltText: form.AltText,
+		Caption: form.Caption,
+	}
+	if !checkMediaChange(&appG, &mediaService) {
+		return
+	}
+
+	if err := mediaService.Edit(); err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_EDIT_MEDIA_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// @Summary Delete a media no article uses, as its uploader or an administrator
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 403 {object} app.Response
+// @Failure 409 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/media/{id} [delete]
+func DeleteMedia(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid.Min(id, 1, "id").Message("ID必须大于0")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	mediaService := media_service.Media{ID: id}
+	if !checkMediaChange(&appG, &mediaService) {
+		return
+	}
+
+	err := mediaService.Delete()
+	if err == media_service.ErrMediaInUse {
+		respondMediaInUse(appG, &mediaService)
+		return
+	}
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_MEDIA_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// respondMediaInUse answers the deletion of a media articles use with their IDs
+func respondMediaInUse(appG app.Gin, mediaService *media_service.Media) {
+	articles, err := mediaService.GetArticles()
+	if err != nil {
+		logging.Warn(err)
+		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_MEDIA_FAIL, nil)
+		return
+	}
+
+	ids := make([]int, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+	appG.Response(http.StatusConflict, e.ERROR_MEDIA_IN_USE, map[string]interface{}{
+		"article_ids": ids,
+	})
+}
+
+// checkMediaExist writes the error response and returns false unless the media exists
+func checkMediaExist(appG *app.Gin, mediaService *media_service.Media) bool {
+	exists, err := mediaService.ExistByID()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_MEDIA_FAIL, nil)
+		return false
+	}
+	if !exists {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_MEDIA, nil)
+		return false
+	}
+
+	return true
+}
+
+// checkMediaChange writes the error response and returns false unless the media
+// exists and the user may change it, as its uploader or an administrator
+func checkMediaChange(appG *app.Gin, mediaService *media_service.Media) bool {
+	media, err := mediaService.Get()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_MEDIA_FAIL, nil)
+		return false
+	}
+	if media.ID == 0 {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_MEDIA, nil)
+		return false
+	}
+	if !quota.GetUser(appG.C).CanChange(media.Owner) {
+		appG.Response(http.StatusForbidden, e.ERROR_AUTH_FORBIDDEN, nil)
+		return false
+	}
+
+	return true
+}
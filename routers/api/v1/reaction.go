@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/reaction_service"
+)
+
+// @Summary Add article reaction
+// @Produce  json
+// @Param id path int true "ID"
+// @Param kind body string true "Kind"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/reactions/{id} [post]
+func AddArticleReaction(c *gin.Context) {
+	reactToArticle(c, c.PostForm("kind"), true)
+}
+
+// @Summary Delete article reaction
+// @Produce  json
+// @Param id path int true "ID"
+// @Param kind path string true "Kind"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/reactions/{id}/{kind} [delete]
+func DeleteArticleReaction(c *gin.Context) {
+	reactToArticle(c, c.Param("kind"), false)
+}
+
+func reactToArticle(c *gin.Context, kind string, add bool) {
+	appG := app.Gin{C: c}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid := validation.Validation{}
+	valid.Min(id, 1, "id")
+	valid.Required(kind, "kind")
+
+	if valid.HasErrors() || !reaction_service.CheckKind(kind) {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	articleService := article_service.Article{ID: id}
+	exists, err := articleService.ExistByID()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_ARTICLE_FAIL, nil)
+		return
+	}
+	if !exists {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ARTICLE, nil)
+		return
+	}
+
+	reactionService := reaction_service.Reaction{
+		ArticleID: id,
+		Kind:      kind,
+		Actor:     getActor(c),
+	}
+	if add {
+		_, err = reactionService.Add()
+	} else {
+		_, err = reactionService.Remove()
+	}
+	if err != nil {
+		code := e.ERROR_ADD_ARTICLE_REACTION_FAIL
+		if !add {
+			code = e.ERROR_DELETE_ARTICLE_REACTION_FAIL
+		}
+		appG.Response(http.StatusInternalServerError, code, nil)
+		return
+	}
+
+	counts, err := reactionService.Count()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_ARTICLE_REACTIONS_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"reactions": counts,
+	})
+}
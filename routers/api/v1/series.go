@@ -0,0 +1,377 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/astaxie/beego/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/series_service"
+)
+
+// @Summary Get multiple series
+// @Produce  json
+// @Param state query int false "State"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/series [get]
+func GetSeriesList(c *gin.Context) {
+	appG := app.Gin{C: c}
+	state := -1
+	if arg := c.Query("state"); arg != "" {
+		state = com.StrTo(arg).MustInt()
+	}
+
+	seriesService := series_service.Series{
+		State:    state,
+		PageNum:  util.GetPage(c),
+		PageSize: setting.AppSetting.PageSize,
+	}
+	series, err := seriesService.GetAll()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_SERIES_FAIL, nil)
+		return
+	}
+
+	count, err := seriesService.Count()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_COUNT_SERIES_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"lists": series,
+		"total": count,
+	})
+}
+
+// @Summary Get a single series with its articles in order
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/series/{id} [get]
+func GetSeries(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid := validation.Validation{}
+	valid.Min(id, 1, "id")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	seriesService := series_service.Series{ID: id}
+	if !checkSeriesExist(&appG, &seriesService) {
+		return
+	}
+
+	series, err := seriesService.Get()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_SERIES_FAIL, nil)
+		return
+	}
+
+	articles, err := seriesService.GetArticles()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_GET_SERIES_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, map[string]interface{}{
+		"series":   series,
+		"articles": articles,
+	})
+}
+
+type AddSeriesForm struct {
+	Title     string `form:"title" valid:"Required;MaxSize(100)"`
+	Desc      string `form:"desc" valid:"MaxSize(255)"`
+	CreatedBy string `form:"created_by" valid:"Required;MaxSize(100)"`
+	State     int    `form:"state" valid:"Range(0,1)"`
+}
+
+// @Summary Add series
+// @Produce  json
+// @Param title body string true "Title"
+// @Param desc body string false "Desc"
+// @Param created_by body string true "CreatedBy"
+// @Param state body int false "State"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/series [post]
+func AddSeries(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form AddSeriesForm
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	seriesService := series_service.Series{
+		Title:     form.Title,
+		Desc:      form.Desc,
+		CreatedBy: form.CreatedBy,
+		State:     form.State,
+	}
+	if err := seriesService.Add(); err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_ADD_SERIES_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+type EditSeriesForm struct {
+	ID         int    `form:"id" valid:"Required;Min(1)"`
+	Title      string `form:"title" valid:"Required;MaxSize(100)"`
+	Desc       string `form:"desc" valid:"MaxSize(255)"`
+	ModifiedBy string `form:"modified_by" valid:"Required;MaxSize(100)"`
+	State      int    `form:"state" valid:"Range(0,1)"`
+}
+
+// @Summary Update series
+// @Produce  json
+// @Param id path int true "ID"
+// @Param title body string true "Title"
+// @Param desc body string false "Desc"
+// @Param modified_by body string true "ModifiedBy"
+// @Param state body int false "State"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/series/{id} [put]
+func EditSeries(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form = EditSeriesForm{ID: com.StrTo(c.Param("id")).MustInt()}
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	seriesService := series_service.Series{
+		ID:         form.ID,
+		Title:      form.Title,
+		Desc:       form.Desc,
+		ModifiedBy: form.ModifiedBy,
+		State:      form.State,
+	}
+	if !checkSeriesExist(&appG, &seriesService) {
+		return
+	}
+
+	if err := seriesService.Edit(); err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_EDIT_SERIES_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// @Summary Delete series
+// @Produce  json
+// @Param id path int true "ID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/series/{id} [delete]
+func DeleteSeries(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+	id := com.StrTo(c.Param("id")).MustInt()
+	valid.Min(id, 1, "id").Message("ID必须大于0")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	seriesService := series_service.Series{ID: id}
+	if !checkSeriesExist(&appG, &seriesService) {
+		return
+	}
+
+	if err := seriesService.Delete(); err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_SERIES_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+type AddSeriesArticleForm struct {
+	ID        int `form:"id" valid:"Required;Min(1)"`
+	ArticleID int `form:"article_id" valid:"Required;Min(1)"`
+	Position  int `form:"position" valid:"Min(0)"`
+}
+
+// @Summary Insert an article into a series
+// @Produce  json
+// @Param id path int true "ID"
+// @Param article_id body int true "ArticleID"
+// @Param position body int false "Position, appended when omitted"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/series/{id}/articles [post]
+func AddSeriesArticle(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form = AddSeriesArticleForm{ID: com.StrTo(c.Param("id")).MustInt()}
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	seriesService := series_service.Series{
+		ID:        form.ID,
+		ArticleID: form.ArticleID,
+		Position:  form.Position,
+	}
+	if !checkSeriesExist(&appG, &seriesService) {
+		return
+	}
+
+	articleService := article_service.Article{ID: form.ArticleID}
+	exists, err := articleService.ExistByID()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_ARTICLE_FAIL, nil)
+		return
+	}
+	if !exists {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ARTICLE, nil)
+		return
+	}
+
+	seriesID, err := seriesService.InArticleSeries()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_ADD_SERIES_ARTICLE_FAIL, nil)
+		return
+	}
+	if seriesID > 0 {
+		appG.Response(http.StatusOK, e.ERROR_EXIST_SERIES_ARTICLE, nil)
+		return
+	}
+
+	if err := seriesService.InsertArticle(); err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_ADD_SERIES_ARTICLE_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// @Summary Remove an article from a series
+// @Produce  json
+// @Param id path int true "ID"
+// @Param article_id path int true "ArticleID"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/series/{id}/articles/{article_id} [delete]
+func DeleteSeriesArticle(c *gin.Context) {
+	appG := app.Gin{C: c}
+	valid := validation.Validation{}
+	id := com.StrTo(c.Param("id")).MustInt()
+	articleID := com.StrTo(c.Param("article_id")).MustInt()
+	valid.Min(id, 1, "id")
+	valid.Min(articleID, 1, "article_id")
+
+	if valid.HasErrors() {
+		app.MarkErrors(valid.Errors)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	seriesService := series_service.Series{ID: id, ArticleID: articleID}
+	seriesID, err := seriesService.InArticleSeries()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_SERIES_ARTICLE_FAIL, nil)
+		return
+	}
+	if seriesID != id {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_ARTICLE, nil)
+		return
+	}
+
+	if err := seriesService.RemoveArticle(); err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_DELETE_SERIES_ARTICLE_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+type SortSeriesArticlesForm struct {
+	ID         int   `form:"id" valid:"Required;Min(1)"`
+	ArticleIDs []int `form:"article_ids" valid:"Required"`
+}
+
+// @Summary Reorder the articles of a series
+// @Produce  json
+// @Param id path int true "ID"
+// @Param article_ids body []int true "ArticleIDs in reading order"
+// @Success 200 {object} app.Response
+// @Failure 500 {object} app.Response
+// @Router /api/v1/series/{id}/articles [put]
+func SortSeriesArticles(c *gin.Context) {
+	var (
+		appG = app.Gin{C: c}
+		form = SortSeriesArticlesForm{ID: com.StrTo(c.Param("id")).MustInt()}
+	)
+
+	httpCode, errCode := app.BindAndValid(c, &form)
+	if errCode != e.SUCCESS {
+		appG.Response(httpCode, errCode, nil)
+		return
+	}
+
+	seriesService := series_service.Series{ID: form.ID, ArticleIDs: form.ArticleIDs}
+	if !checkSeriesExist(&appG, &seriesService) {
+		return
+	}
+
+	err := seriesService.SortArticles()
+	if err == models.ErrSeriesArticlesMismatch {
+		appG.Response(http.StatusBadRequest, e.ERROR_SERIES_ARTICLES_MISMATCH, nil)
+		return
+	}
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_SORT_SERIES_ARTICLES_FAIL, nil)
+		return
+	}
+
+	appG.Response(http.StatusOK, e.SUCCESS, nil)
+}
+
+// checkSeriesExist writes the error response and returns false unless the series exists
+func checkSeriesExist(appG *app.Gin, seriesService *series_service.Series) bool {
+	exists, err := seriesService.ExistByID()
+	if err != nil {
+		appG.Response(http.StatusInternalServerError, e.ERROR_CHECK_EXIST_SERIES_FAIL, nil)
+		return false
+	}
+	if !exists {
+		appG.Response(http.StatusOK, e.ERROR_NOT_EXIST_SERIES, nil)
+		return false
+	}
+
+	return true
+}
@@ -1,7 +1,10 @@
 package v1
 
 import (
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/unknwon/com"
 	"github.com/astaxie/beego/validation"
@@ -13,6 +16,7 @@ import (
 	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
 	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/job_service"
 	"github.com/EDDYCJY/go-gin-example/service/tag_service"
 )
 
@@ -201,6 +205,10 @@ func DeleteTag(c *gin.Context) {
 // @Produce  json
 // @Param name body string false "Name"
 // @Param state body int false "State"
+// @Param format body string false "xlsx, csv or json, otherwise taken from Accept, xlsx for */* or none"
+// @Param delimiter body string false "CSV delimiter, a single character or tab"
+// @Param bom body bool false "Start CSV files with a byte order mark"
+// @Param stream body bool false "Write the file into the response instead of queueing a job"
 // @Success 200 {object} app.Response
 // @Failure 500 {object} app.Response
 // @Router /api/v1/tags/export [post]
@@ -217,41 +225,143 @@ func ExportTag(c *gin.Context) {
 		State: state,
 	}
 
-	filename, err := tagService.Export()
+	// Accept only picks a format it names exactly, */* keeps xlsx
+	format, err := getTagFormat(c, c.GetHeader("Accept"))
 	if err != nil {
-		appG.Response(http.StatusInternalServerError, e.ERROR_EXPORT_TAG_FAIL, nil)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+		return
+	}
+
+	if wantsStream(c) {
+		streamExport(appG, export.GetFileName("tags", "", format.Ext()), func(w io.Writer) error {
+			return tagService.Write(w, format, nil)
+		}, e.ERROR_EXPORT_TAG_FAIL)
 		return
 	}
 
-	appG.Response(http.StatusOK, e.SUCCESS, map[string]string{
-		"export_url":      export.GetExcelFullUrl(filename),
-		"export_save_url": export.GetExcelPath() + filename,
+	job, err := job_service.Enqueue(job_service.KIND_EXPORT_TAGS, func(id string, progress export.ProgressFunc) (string, error) {
+		return tagService.Export(format, id, progress)
 	})
+	respondExportJob(appG, job, err)
 }
 
 // @Summary Import article tag
 // @Produce  json
-// @Param file body file true "Excel File"
+// @Param file body file true "Excel, CSV or JSON file, or the raw body with a matching Content-Type"
+// @Param format body string false "xlsx, csv or json, otherwise taken from the Content-Type or file name"
+// @Param delimiter body string false "CSV delimiter, a single character or tab"
+// @Param created_by body string false "CreatedBy of new tags without one in the sheet"
+// @Param modified_by body string false "ModifiedBy"
+// @Param dry_run body bool false "Only check the sheet"
 // @Success 200 {object} app.Response
+// @Failure 413 {object} app.Response
 // @Failure 500 {object} app.Response
 // @Router /api/v1/tags/import [post]
 func ImportTag(c *gin.Context) {
 	appG := app.Gin{C: c}
+	limit := app.LimitBody(c, setting.ImportSetting.MaxSize)
+	if limit.Exceeded() {
+		appG.Response(http.StatusRequestEntityTooLarge, e.ERROR_IMPORT_TAG_TOO_LARGE, nil)
+		return
+	}
 
-	file, _, err := c.Request.FormFile("file")
+	var (
+		r      io.Reader
+		format *tag_service.Format
+		err    error
+	)
+	if contentType := c.ContentType(); tag_service.GetFormatByContentType(contentType) != "" {
+		r = c.Request.Body
+		format, err = getTagFormat(c, contentType)
+	} else {
+		file, header, ferr := c.Request.FormFile("file")
+		if limit.Exceeded() {
+			appG.Response(http.StatusRequestEntityTooLarge, e.ERROR_IMPORT_TAG_TOO_LARGE, nil)
+			return
+		}
+		if ferr != nil {
+			logging.Warn(ferr)
+			appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
+			return
+		}
+		defer file.Close()
+
+		r = file
+		format, err = getTagFormat(c, header.Header.Get("Content-Type"), header.Filename)
+	}
 	if err != nil {
-		logging.Warn(err)
-		appG.Response(http.StatusInternalServerError, e.ERROR, nil)
+		appG.Response(http.StatusBadRequest, e.INVALID_PARAMS, nil)
 		return
 	}
 
-	tagService := tag_service.Tag{}
-	err = tagService.Import(file)
+	tagService := tag_service.Tag{
+		CreatedBy:  c.Request.FormValue("created_by"),
+		ModifiedBy: c.Request.FormValue("modified_by"),
+	}
+	dryRun, _ := strconv.ParseBool(c.Request.FormValue("dry_run"))
+	report, err := tagService.Import(r, format, dryRun)
+	if limit.Exceeded() {
+		appG.Response(http.StatusRequestEntityTooLarge, e.ERROR_IMPORT_TAG_TOO_LARGE, nil)
+		return
+	}
+	if err == tag_service.ErrNoNameColumn || err == tag_service.ErrMalformedFile {
+		appG.Response(http.StatusBadRequest, e.ERROR_IMPORT_TAG_FORMAT, nil)
+		return
+	}
 	if err != nil {
 		logging.Warn(err)
 		appG.Response(http.StatusInternalServerError, e.ERROR_IMPORT_TAG_FAIL, nil)
 		return
 	}
 
-	appG.Response(http.StatusOK, e.SUCCESS, nil)
+	data := map[string]interface{}{"report": report}
+	if report.ReportFile == "" {
+		appG.Response(http.StatusOK, e.SUCCESS, data)
+		return
+	}
+
+	data["report_url"] = export.GetExcelFullUrl(report.ReportFile)
+	data["report_save_url"] = export.GetExcelPath() + report.ReportFile
+	appG.Response(http.StatusBadRequest, e.ERROR_IMPORT_TAG_INVALID_ROWS, data)
+}
+
+// getTagFormat picks the format of a tag table from the format parameter, then
+// from the first of hints that names one, either a media type list or a file name,
+// and defaults to xlsx. The CSV options of the request override the settings
+func getTagFormat(c *gin.Context, hints ...string) (*tag_service.Format, error) {
+	name := c.Request.FormValue("format")
+	for _, hint := range hints {
+		if name != "" {
+			break
+		}
+		for _, mediaType := range strings.Split(hint, ",") {
+			if name = tag_service.GetFormatByContentType(mediaType); name != "" {
+				break
+			}
+		}
+		if name == "" {
+			name = tag_service.GetFormatByFileName(hint)
+		}
+	}
+	if name == "" {
+		name = tag_service.FORMAT_XLSX
+	}
+
+	format, err := tag_service.NewFormat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if arg := c.Request.FormValue("delimiter"); arg != "" {
+		if format.Delimiter, err = tag_service.ParseDelimiter(arg); err != nil {
+			return nil, err
+		}
+	}
+	if arg := c.Request.FormValue("bom"); arg != "" {
+		if format.BOM, err = strconv.ParseBool(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	return format, nil
 }
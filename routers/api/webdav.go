@@ -0,0 +1,127 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/webdav"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/app"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/api_key_service"
+	"github.com/EDDYCJY/go-gin-example/service/auth_service"
+	"github.com/EDDYCJY/go-gin-example/service/quota_service"
+	"github.com/EDDYCJY/go-gin-example/service/webdav_service"
+)
+
+var webdavHandler = &webdav.Handler{
+	Prefix:     "/webdav",
+	FileSystem: webdav_service.NewFS(),
+	LockSystem: webdav.NewMemLS(),
+	Logger: func(r *http.Request, err error) {
+		if err != nil {
+			logging.Warn("webdav", r.Method, r.URL.Path, "err:", err)
+		}
+	},
+}
+
+// WebDAV serves the media library for mounting in a file manager. Clients
+// sign in with HTTP basic auth, with the blog account and either its password
+// or one of its API keys. Client addresses failing to sign in too often are
+// held off for a while. Files written are checked before the body is read as
+// far as the request tells, so that a refused file is not sent whole
+func WebDAV(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ip := c.ClientIP()
+	if ok, wait := webdav_service.AllowSignIn(ip); !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		appG.Response(http.StatusTooManyRequests, e.ERROR_AUTH_TOO_MANY_FAILURES, nil)
+		return
+	}
+
+	var user *quota_service.User
+	username, password, ok := c.Request.BasicAuth()
+	if ok {
+		var err error
+		user, ok, err = getWebDAVUser(username, password)
+		if err != nil {
+			logging.Warn(err)
+			appG.Response(http.StatusInternalServerError, e.ERROR_AUTH_CHECK_TOKEN_FAIL, nil)
+			return
+		}
+		if !ok {
+			webdav_service.FailSignIn(ip)
+		}
+	}
+	if !ok {
+		c.Header("WWW-Authenticate", `Basic realm="media", charset="UTF-8"`)
+		appG.Response(http.StatusUnauthorized, e.ERROR_AUTH, nil)
+		return
+	}
+
+	name := c.Param("path")
+	if c.Request.Method == http.MethodPut && !webdav_service.IsDotFile(name) {
+		if !upload.CheckImageExt(path.Ext(name)) {
+			appG.Response(http.StatusUnsupportedMediaType, e.ERROR_UPLOAD_CHECK_IMAGE_FORMAT, nil)
+			return
+		}
+
+		// a chunked body has no length to check up front, it is checked as it is saved
+		if c.Request.ContentLength > 0 {
+			if c.Request.ContentLength > int64(setting.AppSetting.ImageMaxSize) {
+				appG.Response(http.StatusRequestEntityTooLarge, e.ERROR_UPLOAD_IMAGE_TOO_LARGE, nil)
+				return
+			}
+
+			switch err := user.Check(c.Request.ContentLength); err {
+			case nil:
+			case quota_service.ErrQuotaExceeded:
+				appG.Response(http.StatusInsufficientStorage, e.ERROR_UPLOAD_QUOTA_EXCEEDED, nil)
+				return
+			default:
+				logging.Warn(err)
+				appG.Response(http.StatusInternalServerError, e.ERROR_GET_UPLOAD_USAGE_FAIL, nil)
+				return
+			}
+		}
+
+		if ok, wait := user.Allow(); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			appG.Response(http.StatusTooManyRequests, e.ERROR_UPLOAD_RATE_LIMIT, nil)
+			return
+		}
+	}
+
+	r := c.Request.WithContext(webdav_service.WithUser(c.Request.Context(), user))
+	webdavHandler.ServeHTTP(c.Writer, r)
+}
+
+// getWebDAVUser signs in an account with its password or one of its API keys
+func getWebDAVUser(username, password string) (*quota_service.User, bool, error) {
+	var isExist bool
+	var err error
+	if api_key_service.IsKey(password) {
+		isExist, err = api_key_service.Check(username, password)
+	} else {
+		authService := auth_service.Auth{Username: username, Password: password}
+		isExist, err = authService.Check()
+	}
+	if err != nil || !isExist {
+		return nil, false, err
+	}
+
+	authService := auth_service.Auth{Username: username}
+	role, err := authService.GetRole()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &quota_service.User{Owner: util.EncodeMD5(username), Role: role, Name: username}, true, nil
+}
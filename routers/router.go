@@ -10,11 +10,14 @@ import (
 	"github.com/swaggo/gin-swagger/swaggerFiles"
 
 	"github.com/EDDYCJY/go-gin-example/middleware/jwt"
-	"github.com/EDDYCJY/go-gin-example/pkg/export"
-	"github.com/EDDYCJY/go-gin-example/pkg/qrcode"
-	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/middleware/quota"
+	"github.com/EDDYCJY/go-gin-example/middleware/tus"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/sitemap"
+	"github.com/EDDYCJY/go-gin-example/pkg/theme"
 	"github.com/EDDYCJY/go-gin-example/routers/api"
 	"github.com/EDDYCJY/go-gin-example/routers/api/v1"
+	"github.com/EDDYCJY/go-gin-example/routers/web"
 )
 
 // InitRouter initialize routing information
@@ -23,13 +26,55 @@ func InitRouter() *gin.Engine {
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
 
-	r.StaticFS("/export", http.Dir(export.GetExcelFullPath()))
-	r.StaticFS("/upload/images", http.Dir(upload.GetImageFullPath()))
-	r.StaticFS("/qrcode", http.Dir(qrcode.GetQrCodeFullPath()))
+	r.GET("/upload/images/*name", api.GetImage)
+	r.HEAD("/upload/images/*name", api.GetImage)
+	r.GET("/qrcode/*name", api.GetQrCode)
+	r.HEAD("/qrcode/*name", api.GetQrCode)
+	r.StaticFS("/sitemaps", http.Dir(sitemap.GetSitemapFullPath()))
 
 	r.GET("/auth", api.GetAuth)
+	r.GET("/export/:name", api.DownloadExport)
+	r.GET("/attachments/:id", api.DownloadAttachment)
+	r.HEAD("/attachments/:id", api.DownloadAttachment)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	r.POST("/upload", api.UploadImage)
+	r.POST("/upload", jwt.JWT(), quota.RateLimit(), api.UploadImage)
+
+	//查询断点续传支持的协议与扩展
+	r.OPTIONS("/upload/files", tus.Resumable(), api.TusOptions)
+	files := r.Group("/upload/files")
+	files.Use(tus.Resumable(), jwt.JWT())
+	{
+		//创建断点续传上传
+		files.POST("", quota.RateLimit(), api.CreateTusUpload)
+		//查询已上传的偏移量
+		files.HEAD("/:id", api.HeadTusUpload)
+		//续传文件内容
+		files.PATCH("/:id", api.PatchTusUpload)
+		//取消上传
+		files.DELETE("/:id", api.DeleteTusUpload)
+	}
+	//获取断点续传上传及其生成的媒体
+	r.GET("/upload/files/:id", jwt.JWT(), api.GetTusUpload)
+
+	//通过WebDAV挂载媒体库
+	for _, method := range []string{"OPTIONS", "GET", "HEAD", "PUT", "DELETE", "PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"} {
+		r.Handle(method, "/webdav/*path", api.WebDAV)
+	}
+
+	r.GET("/sitemap.xml", api.GetSitemap)
+	r.GET("/robots.txt", api.GetRobots)
+	r.GET("/feed/:format", api.GetFeed)
+	r.GET("/tags/:id/feed/:format", api.GetTagFeed)
+	r.GET("/authors/:name/feed/:format", api.GetAuthorFeed)
+
+	reactions := r.Group("/api/v1/reactions")
+	reactions.Use(jwt.OptionalJWT())
+	{
+		//新增文章表态
+		reactions.POST("/:id", v1.AddArticleReaction)
+		//取消文章表态
+		reactions.DELETE("/:id/:kind", v1.DeleteArticleReaction)
+	}
 
 	apiv1 := r.Group("/api/v1")
 	apiv1.Use(jwt.JWT())
@@ -43,14 +88,16 @@ func InitRouter() *gin.Engine {
 		//删除指定标签
 		apiv1.DELETE("/tags/:id", v1.DeleteTag)
 		//导出标签
-		r.POST("/tags/export", v1.ExportTag)
+		apiv1.POST("/tags/export", v1.ExportTag)
 		//导入标签
-		r.POST("/tags/import", v1.ImportTag)
+		apiv1.POST("/tags/import", v1.ImportTag)
 
 		//获取文章列表
 		apiv1.GET("/articles", v1.GetArticles)
 		//获取指定文章
 		apiv1.GET("/articles/:id", v1.GetArticle)
+		//获取相关文章
+		apiv1.GET("/articles/:id/related", v1.GetRelatedArticles)
 		//新建文章
 		apiv1.POST("/articles", v1.AddArticle)
 		//更新指定文章
@@ -59,6 +106,76 @@ func InitRouter() *gin.Engine {
 		apiv1.DELETE("/articles/:id", v1.DeleteArticle)
 		//生成文章海报
 		apiv1.POST("/articles/poster/generate", v1.GenerateArticlePoster)
+		//导入Markdown文章
+		apiv1.POST("/articles/import", v1.ImportArticles)
+		//导出文章
+		apiv1.POST("/articles/export", v1.ExportArticles)
+
+		//获取导出任务进度
+		apiv1.GET("/exports/:id", v1.GetExportJob)
+
+		//备份全站
+		apiv1.POST("/backups", jwt.Admin(), v1.AddBackup)
+		//从备份恢复
+		apiv1.POST("/backups/restore", jwt.Admin(), v1.RestoreBackup)
+
+		//获取媒体库列表
+		apiv1.GET("/media", v1.GetMediaList)
+		//获取指定媒体及引用它的文章
+		apiv1.GET("/media/:id", v1.GetMedia)
+		//更新媒体描述
+		apiv1.PUT("/media/:id", v1.EditMedia)
+		//删除未被引用的媒体
+		apiv1.DELETE("/media/:id", v1.DeleteMedia)
+		//隔离未被引用的上传文件并清除过期的隔离文件
+		apiv1.POST("/gc", jwt.Admin(), v1.CollectUploads)
+		//恢复隔离的文件
+		apiv1.POST("/gc/restore", jwt.Admin(), v1.RestoreQuarantine)
+
+		//获取可上传的附件类型
+		apiv1.GET("/attachments/types", v1.GetAttachmentTypes)
+		//获取文章附件列表
+		apiv1.GET("/articles/:id/attachments", v1.GetArticleAttachments)
+		//上传文章附件
+		apiv1.POST("/attachments", quota.RateLimit(), v1.AddAttachment)
+		//删除附件
+		apiv1.DELETE("/attachments/:id", v1.DeleteAttachment)
+		//获取当前用户的上传空间用量
+		apiv1.GET("/uploads/usage", v1.GetUploadUsage)
+
+		//获取当前用户的API密钥
+		apiv1.GET("/api-keys", v1.GetApiKeys)
+		//创建API密钥，用于登录WebDAV
+		apiv1.POST("/api-keys", v1.AddApiKey)
+		//删除API密钥
+		apiv1.DELETE("/api-keys/:id", v1.DeleteApiKey)
+
+		//获取系列列表
+		apiv1.GET("/series", v1.GetSeriesList)
+		//获取指定系列及其文章
+		apiv1.GET("/series/:id", v1.GetSeries)
+		//新建系列
+		apiv1.POST("/series", v1.AddSeries)
+		//更新指定系列
+		apiv1.PUT("/series/:id", v1.EditSeries)
+		//删除指定系列
+		apiv1.DELETE("/series/:id", v1.DeleteSeries)
+		//插入系列文章
+		apiv1.POST("/series/:id/articles", v1.AddSeriesArticle)
+		//调整系列文章顺序
+		apiv1.PUT("/series/:id/articles", v1.SortSeriesArticles)
+		//移出系列文章
+		apiv1.DELETE("/series/:id/articles/:article_id", v1.DeleteSeriesArticle)
+	}
+
+	if setting.FrontendSetting.Enabled {
+		r.StaticFS("/static", http.Dir(theme.GetStaticFullPath()))
+
+		r.GET("/", web.GetIndex)
+		r.GET("/archive", web.GetArchive)
+		r.GET("/articles/:id", web.GetArticle)
+		r.GET("/tags/:id", web.GetTag)
+		r.GET("/authors/:name", web.GetAuthor)
 	}
 
 	return r
@@ -0,0 +1,174 @@
+package web
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unknwon/com"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/theme"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/related_service"
+	"github.com/EDDYCJY/go-gin-example/service/tag_service"
+)
+
+// GetIndex renders the latest published articles
+func GetIndex(c *gin.Context) {
+	articleService := article_service.Article{
+		TagID:    -1,
+		State:    1,
+		PageNum:  util.GetPage(c),
+		PageSize: setting.AppSetting.PageSize,
+	}
+
+	renderList(c, "index", "", &articleService)
+}
+
+// GetTag renders the published articles of a tag
+func GetTag(c *gin.Context) {
+	id := com.StrTo(c.Param("id")).MustInt()
+	tagService := tag_service.Tag{ID: id}
+	exists, err := tagService.ExistByID()
+	if err != nil {
+		renderError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if !exists {
+		renderError(c, http.StatusNotFound, nil)
+		return
+	}
+
+	tag, err := tagService.Get()
+	if err != nil {
+		renderError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	articleService := article_service.Article{
+		TagID:    id,
+		State:    1,
+		PageNum:  util.GetPage(c),
+		PageSize: setting.AppSetting.PageSize,
+	}
+
+	renderList(c, "tag", tag.Name, &articleService)
+}
+
+// GetAuthor renders the published articles of an author
+func GetAuthor(c *gin.Context) {
+	articleService := article_service.Article{
+		TagID:     -1,
+		State:     1,
+		CreatedBy: c.Param("name"),
+		PageNum:   util.GetPage(c),
+		PageSize:  setting.AppSetting.PageSize,
+	}
+
+	renderList(c, "tag", articleService.CreatedBy, &articleService)
+}
+
+// GetArticle renders a published article with its related articles
+func GetArticle(c *gin.Context) {
+	id := com.StrTo(c.Param("id")).MustInt()
+	articleService := article_service.Article{ID: id}
+	exists, err := articleService.ExistByID()
+	if err != nil {
+		renderError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if !exists {
+		renderError(c, http.StatusNotFound, nil)
+		return
+	}
+
+	article, err := articleService.Get()
+	if err != nil {
+		renderError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if article.State != 1 {
+		renderError(c, http.StatusNotFound, nil)
+		return
+	}
+
+	relatedService := related_service.Related{ArticleID: id}
+	related, err := relatedService.Get()
+	if err != nil {
+		logging.Info(err)
+	}
+
+	render(c, http.StatusOK, "article", &theme.Page{
+		Title: article.Title,
+		Data: map[string]interface{}{
+			"Article": article,
+			"Related": related,
+		},
+	})
+}
+
+// GetArchive renders every published article grouped by month
+func GetArchive(c *gin.Context) {
+	articleService := article_service.Article{TagID: -1, State: 1}
+	months, err := articleService.GetArchive()
+	if err != nil {
+		renderError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	render(c, http.StatusOK, "archive", &theme.Page{Title: "Archive", Data: months})
+}
+
+func renderList(c *gin.Context, name, title string, articleService *article_service.Article) {
+	articles, err := articleService.GetLatest()
+	if err != nil {
+		renderError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	total, err := articleService.Count()
+	if err != nil {
+		renderError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	page := &theme.Page{
+		Title: title,
+		Data:  articles,
+		Page:  articleService.PageNum/articleService.PageSize + 1,
+	}
+	if page.Page > 1 {
+		page.PrevUrl = "?page=" + strconv.Itoa(page.Page-1)
+	}
+	if articleService.PageNum+len(articles) < total {
+		page.NextUrl = "?page=" + strconv.Itoa(page.Page+1)
+	}
+
+	render(c, http.StatusOK, name, page)
+}
+
+func renderError(c *gin.Context, code int, err error) {
+	if err != nil {
+		logging.Warn(err)
+	}
+
+	render(c, code, "error", &theme.Page{Title: http.StatusText(code), Data: code})
+}
+
+// render executes into a buffer first so a template error never leaves a half-written page
+func render(c *gin.Context, code int, name string, page *theme.Page) {
+	page.SiteTitle = setting.FrontendSetting.Title
+
+	var buf bytes.Buffer
+	if err := theme.Render(&buf, name, page); err != nil {
+		logging.Error(err)
+		c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	c.Data(code, "text/html; charset=utf-8", buf.Bytes())
+}
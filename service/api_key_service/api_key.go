@@ -0,0 +1,102 @@
+package api_key_service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+)
+
+// keyPrefix starts every key, telling keys apart from passwords
+const keyPrefix = "gbk_"
+
+// keyBytes is how many random bytes a key holds
+const keyBytes = 32
+
+// ApiKey is a key of an account to create, list or delete
+type ApiKey struct {
+	ID       int
+	Username string
+	Name     string
+}
+
+// Add creates a key for the account. The key is returned in clear only here,
+// what is kept is its hash
+func (k *ApiKey) Add() (*models.ApiKey, string, error) {
+	key, err := generateKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	apiKey := &models.ApiKey{
+		Username: k.Username,
+		Name:     k.Name,
+		KeyHash:  hashKey(key),
+	}
+	if err := models.AddApiKey(apiKey); err != nil {
+		return nil, "", err
+	}
+
+	return apiKey, key, nil
+}
+
+func (k *ApiKey) GetAll() ([]*models.ApiKey, error) {
+	return models.GetApiKeys(k.Username)
+}
+
+func (k *ApiKey) Get() (*models.ApiKey, error) {
+	return models.GetApiKey(k.ID)
+}
+
+func (k *ApiKey) Delete() error {
+	return models.DeleteApiKey(k.ID)
+}
+
+// IsKey reports whether a secret is shaped as a key rather than a password
+func IsKey(secret string) bool {
+	if !strings.HasPrefix(secret, keyPrefix) || len(secret) != len(keyPrefix)+2*keyBytes {
+		return false
+	}
+
+	_, err := hex.DecodeString(secret[len(keyPrefix):])
+	return err == nil
+}
+
+// Check reports whether key is a live key of the account, recording its use
+func Check(username, key string) (bool, error) {
+	if !IsKey(key) {
+		return false, nil
+	}
+
+	apiKey, err := models.GetApiKeyByHash(hashKey(key))
+	if err != nil || apiKey.ID == 0 || apiKey.Username != username {
+		return false, err
+	}
+
+	if err := models.UseApiKey(apiKey.ID, int(time.Now().Unix())); err != nil {
+		logging.Warn("api_key_service.Check", apiKey.ID, "err:", err)
+	}
+
+	return true, nil
+}
+
+// generateKey makes a new random key
+func generateKey() (string, error) {
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return keyPrefix + hex.EncodeToString(b), nil
+}
+
+// hashKey is the hash a key is kept and looked up by. The keys are random
+// enough that a plain SHA-256 of them cannot be reversed
+func hashKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
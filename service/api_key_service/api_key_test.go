@@ -0,0 +1,60 @@
+package api_key_service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	key, err := generateKey()
+	if err != nil {
+		t.Fatalf("generateKey() err = %v", err)
+	}
+	if !IsKey(key) {
+		t.Errorf("IsKey(%q) = false, want true", key)
+	}
+
+	other, err := generateKey()
+	if err != nil {
+		t.Fatalf("generateKey() err = %v", err)
+	}
+	if key == other {
+		t.Errorf("generateKey() returned %q twice", key)
+	}
+}
+
+func TestIsKey(t *testing.T) {
+	tests := []struct {
+		secret string
+		want   bool
+	}{
+		{keyPrefix + strings.Repeat("0a", keyBytes), true},
+		{keyPrefix + strings.Repeat("0a", keyBytes-1), false},
+		{keyPrefix + strings.Repeat("zz", keyBytes), false},
+		{strings.Repeat("0a", keyBytes+2), false},
+		{"test123", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsKey(tt.secret); got != tt.want {
+			t.Errorf("IsKey(%q) = %v, want %v", tt.secret, got, tt.want)
+		}
+	}
+}
+
+func TestHashKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	}
+
+	for _, tt := range tests {
+		if got := hashKey(tt.key); got != tt.want {
+			t.Errorf("hashKey(%q) = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
@@ -2,13 +2,24 @@ package article_service
 
 import (
 	"encoding/json"
+	"sort"
+	"time"
 
 	"github.com/EDDYCJY/go-gin-example/models"
 	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
 	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+	"github.com/EDDYCJY/go-gin-example/service/media_service"
+	"github.com/EDDYCJY/go-gin-example/service/reaction_service"
+	"github.com/EDDYCJY/go-gin-example/service/series_service"
+	"github.com/EDDYCJY/go-gin-example/service/sitemap_service"
 )
 
+type ArchiveMonth struct {
+	Month    string
+	Articles []*models.Article
+}
+
 type Article struct {
 	ID            int
 	TagID         int
@@ -19,13 +30,49 @@ type Article struct {
 	State         int
 	CreatedBy     string
 	ModifiedBy    string
+	CreatedOn     int
 
 	PageNum  int
 	PageSize int
 }
 
 func (a *Article) Add() error {
-	article := map[string]interface{}{
+	id, err := models.AddArticle(a.getAddMaps())
+	if err != nil {
+		return err
+	}
+
+	return a.added(id)
+}
+
+// AddImported adds an article imported from another system, recording the
+// item it came from along with it
+func (a *Article) AddImported(source, guid, kind string) error {
+	id, err := models.AddImportedArticle(a.getAddMaps(), source, guid, kind)
+	if err != nil {
+		return err
+	}
+
+	return a.added(id)
+}
+
+func (a *Article) added(id int) error {
+	a.ID = id
+	sitemap_service.MarkArticle(id)
+	a.trackMedia()
+	return nil
+}
+
+// trackMedia records the media the article uses. The article is saved by then,
+// so a failure is only logged: blogctl media-refs records the references again
+func (a *Article) trackMedia() {
+	if err := media_service.TrackArticle(a.ID, a.CoverImageUrl, a.Content); err != nil {
+		logging.Warn("article_service.trackMedia", a.ID, "err:", err)
+	}
+}
+
+func (a *Article) getAddMaps() map[string]interface{} {
+	return map[string]interface{}{
 		"tag_id":          a.TagID,
 		"title":           a.Title,
 		"desc":            a.Desc,
@@ -33,17 +80,12 @@ func (a *Article) Add() error {
 		"created_by":      a.CreatedBy,
 		"cover_image_url": a.CoverImageUrl,
 		"state":           a.State,
+		"created_on":      a.CreatedOn,
 	}
-
-	if err := models.AddArticle(article); err != nil {
-		return err
-	}
-
-	return nil
 }
 
 func (a *Article) Edit() error {
-	return models.EditArticle(a.ID, map[string]interface{}{
+	err := models.EditArticle(a.ID, map[string]interface{}{
 		"tag_id":          a.TagID,
 		"title":           a.Title,
 		"desc":            a.Desc,
@@ -52,6 +94,13 @@ func (a *Article) Edit() error {
 		"state":           a.State,
 		"modified_by":     a.ModifiedBy,
 	})
+	if err != nil {
+		return err
+	}
+
+	sitemap_service.MarkArticle(a.ID)
+	a.trackMedia()
+	return nil
 }
 
 func (a *Article) Get() (*models.Article, error) {
@@ -65,7 +114,7 @@ func (a *Article) Get() (*models.Article, error) {
 			logging.Info(err)
 		} else {
 			json.Unmarshal(data, &cacheArticle)
-			return cacheArticle, nil
+			return cacheArticle, fillArticle(cacheArticle)
 		}
 	}
 
@@ -75,7 +124,7 @@ func (a *Article) Get() (*models.Article, error) {
 	}
 
 	gredis.Set(key, article, 3600)
-	return article, nil
+	return article, fillArticle(article)
 }
 
 func (a *Article) GetAll() ([]*models.Article, error) {
@@ -97,7 +146,7 @@ func (a *Article) GetAll() ([]*models.Article, error) {
 			logging.Info(err)
 		} else {
 			json.Unmarshal(data, &cacheArticles)
-			return cacheArticles, nil
+			return cacheArticles, fillReactions(cacheArticles...)
 		}
 	}
 
@@ -107,11 +156,60 @@ func (a *Article) GetAll() ([]*models.Article, error) {
 	}
 
 	gredis.Set(key, articles, 3600)
-	return articles, nil
+	return articles, fillReactions(articles...)
+}
+
+// GetLatest returns a page of articles, newest first, bypassing the list cache
+func (a *Article) GetLatest() ([]*models.Article, error) {
+	return models.GetLatestArticles(a.PageNum, a.PageSize, a.getMaps())
+}
+
+// ListAll returns every matching article without paging or caching
+func (a *Article) ListAll() ([]*models.Article, error) {
+	return models.GetAllArticles(a.getMaps())
+}
+
+// GetArchive returns every matching article grouped by the month it was published, newest first
+func (a *Article) GetArchive() ([]*ArchiveMonth, error) {
+	articles, err := a.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(articles, func(i, j int) bool {
+		return articles[i].CreatedOn > articles[j].CreatedOn
+	})
+
+	var months []*ArchiveMonth
+	for _, article := range articles {
+		month := time.Unix(int64(article.CreatedOn), 0).Format("2006-01")
+		if len(months) == 0 || months[len(months)-1].Month != month {
+			months = append(months, &ArchiveMonth{Month: month})
+		}
+		last := months[len(months)-1]
+		last.Articles = append(last.Articles, article)
+	}
+
+	return months, nil
 }
 
 func (a *Article) Delete() error {
-	return models.DeleteArticle(a.ID)
+	if err := models.DeleteArticle(a.ID); err != nil {
+		return err
+	}
+
+	sitemap_service.MarkArticle(a.ID)
+	if err := models.DeleteArticleComments(a.ID); err != nil {
+		return err
+	}
+	if err := models.DeleteArticleMediaReferences(a.ID); err != nil {
+		return err
+	}
+	reaction := reaction_service.Reaction{ArticleID: a.ID}
+	if err := reaction.RemoveAll(); err != nil {
+		return err
+	}
+	return models.RemoveSeriesArticle(a.ID)
 }
 
 func (a *Article) ExistByID() (bool, error) {
@@ -122,6 +220,36 @@ func (a *Article) Count() (int, error) {
 	return models.GetArticleTotal(a.getMaps())
 }
 
+// fillArticle attaches the live reaction counters and series navigation of a single article
+func fillArticle(article *models.Article) error {
+	nav, err := series_service.GetNav(article.ID)
+	if err != nil {
+		return err
+	}
+	article.Series = nav
+
+	return fillReactions(article)
+}
+
+// fillReactions attaches the live reaction counters, which are not part of the cached article
+func fillReactions(articles ...*models.Article) error {
+	ids := make([]int, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+
+	counts, err := reaction_service.GetCounts(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, article := range articles {
+		article.Reactions = counts[article.ID]
+	}
+
+	return nil
+}
+
 func (a *Article) getMaps() map[string]interface{} {
 	maps := make(map[string]interface{})
 	maps["deleted_on"] = 0
@@ -131,6 +259,9 @@ func (a *Article) getMaps() map[string]interface{} {
 	if a.TagID != -1 {
 		maps["tag_id"] = a.TagID
 	}
+	if a.CreatedBy != "" {
+		maps["created_by"] = a.CreatedBy
+	}
 
 	return maps
 }
@@ -1,17 +1,18 @@
 package article_service
 
 import (
+	"bytes"
 	"image"
 	"image/draw"
 	"image/jpeg"
+	"io"
 	"io/ioutil"
-	"os"
 
 	"github.com/golang/freetype"
 
-	"github.com/EDDYCJY/go-gin-example/pkg/file"
 	"github.com/EDDYCJY/go-gin-example/pkg/qrcode"
 	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
 )
 
 type ArticlePoster struct {
@@ -32,21 +33,14 @@ func GetPosterFlag() string {
 	return "poster"
 }
 
-func (a *ArticlePoster) CheckMergedImage(path string) bool {
-	if file.CheckNotExist(path+a.PosterName) == true {
-		return false
-	}
-
-	return true
+// GetPosterBgName get the name of the background posters are drawn on, which
+// is kept among the posters
+func GetPosterBgName() string {
+	return "bg.jpg"
 }
 
-func (a *ArticlePoster) OpenMergedImage(path string) (*os.File, error) {
-	f, err := file.MustOpen(a.PosterName, path)
-	if err != nil {
-		return nil, err
-	}
-
-	return f, nil
+func (a *ArticlePoster) CheckMergedImage() bool {
+	return storage.Exists(storage.Posters, a.PosterName)
 }
 
 type ArticlePosterBg struct {
@@ -80,7 +74,7 @@ func NewArticlePosterBg(name string, ap *ArticlePoster, rect *Rect, pt *Pt) *Art
 
 type DrawText struct {
 	JPG    draw.Image
-	Merged *os.File
+	Merged io.Writer
 
 	Title string
 	X0    int
@@ -134,36 +128,17 @@ func (a *ArticlePosterBg) DrawPoster(d *DrawText, fontName string) error {
 }
 
 func (a *ArticlePosterBg) Generate() (string, string, error) {
-	fullPath := qrcode.GetQrCodeFullPath()
-	fileName, path, err := a.Qr.Encode(fullPath)
+	fileName, path, err := a.Qr.Encode()
 	if err != nil {
 		return "", "", err
 	}
 
-	if !a.CheckMergedImage(path) {
-		mergedF, err := a.OpenMergedImage(path)
-		if err != nil {
-			return "", "", err
-		}
-		defer mergedF.Close()
-
-		bgF, err := file.MustOpen(a.Name, path)
-		if err != nil {
-			return "", "", err
-		}
-		defer bgF.Close()
-
-		qrF, err := file.MustOpen(fileName, path)
+	if !a.CheckMergedImage() {
+		bgImage, err := decodePoster(a.Name)
 		if err != nil {
 			return "", "", err
 		}
-		defer qrF.Close()
-
-		bgImage, err := jpeg.Decode(bgF)
-		if err != nil {
-			return "", "", err
-		}
-		qrImage, err := jpeg.Decode(qrF)
+		qrImage, err := decodePoster(fileName)
 		if err != nil {
 			return "", "", err
 		}
@@ -173,9 +148,10 @@ func (a *ArticlePosterBg) Generate() (string, string, error) {
 		draw.Draw(jpg, jpg.Bounds(), bgImage, bgImage.Bounds().Min, draw.Over)
 		draw.Draw(jpg, jpg.Bounds(), qrImage, qrImage.Bounds().Min.Sub(image.Pt(a.Pt.X, a.Pt.Y)), draw.Over)
 
+		var merged bytes.Buffer
 		err = a.DrawPoster(&DrawText{
 			JPG:    jpg,
-			Merged: mergedF,
+			Merged: &merged,
 
 			Title: "Golang Gin 系列文章",
 			X0:    80,
@@ -191,7 +167,23 @@ func (a *ArticlePosterBg) Generate() (string, string, error) {
 		if err != nil {
 			return "", "", err
 		}
+
+		err = storage.Posters.Put(a.PosterName, &merged)
+		if err != nil {
+			return "", "", err
+		}
 	}
 
 	return fileName, path, nil
 }
+
+// decodePoster reads a JPEG image kept among the posters, such as the background
+func decodePoster(name string) (image.Image, error) {
+	r, err := storage.Posters.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return jpeg.Decode(r)
+}
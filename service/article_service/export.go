@@ -0,0 +1,244 @@
+package article_service
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/export"
+	"github.com/EDDYCJY/go-gin-example/pkg/frontmatter"
+)
+
+const (
+	FORMAT_MARKDOWN = "markdown"
+	FORMAT_XLSX     = "xlsx"
+	FORMAT_JSONL    = "jsonl"
+)
+
+var exportExts = map[string]string{
+	FORMAT_MARKDOWN: export.ZIP_EXT,
+	FORMAT_XLSX:     export.EXT,
+	FORMAT_JSONL:    export.JSONL_EXT,
+}
+
+// markdownMatter is the front matter of an exported article, in the fields
+// the Markdown importer reads back
+type markdownMatter struct {
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description,omitempty"`
+	Date        string   `yaml:"date"`
+	Lastmod     string   `yaml:"lastmod,omitempty"`
+	Author      string   `yaml:"author,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+	Cover       string   `yaml:"cover,omitempty"`
+	Draft       bool     `yaml:"draft,omitempty"`
+}
+
+// CheckExportFormat checks if the export format is supported
+func CheckExportFormat(format string) bool {
+	_, ok := exportExts[format]
+	return ok
+}
+
+// GetExportExt returns the file extension of an export format
+func GetExportExt(format string) string {
+	return exportExts[format]
+}
+
+// Export stores the matching articles among the export files and returns the file name,
+// which carries the id of the job. progress may be nil
+func (a *Article) Export(format, id string, progress export.ProgressFunc) (string, error) {
+	filename := export.GetFileName("articles", id, exportExts[format])
+
+	err := export.Save(filename, func(w io.Writer) error {
+		return a.Write(w, format, progress)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// Write streams the matching articles to w, reading them from the database a batch
+// at a time so that memory does not grow with the table. progress may be nil
+func (a *Article) Write(w io.Writer, format string, progress export.ProgressFunc) error {
+	if progress == nil {
+		progress = func(done, total int) {}
+	}
+
+	total, err := a.Count()
+	if err != nil {
+		return err
+	}
+
+	aw, err := newArticleWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	batchSize := export.GetBatchSize()
+	done, lastID := 0, 0
+	for {
+		articles, err := models.GetArticlesAfter(lastID, batchSize, a.getMaps())
+		if err != nil {
+			return err
+		}
+
+		for _, article := range articles {
+			if err := aw.Write(article); err != nil {
+				return err
+			}
+
+			done++
+			if done > total {
+				total = done
+			}
+			progress(done, total)
+		}
+
+		if len(articles) < batchSize {
+			break
+		}
+		lastID = articles[len(articles)-1].ID
+	}
+
+	return aw.Close()
+}
+
+// articleWriter writes exported articles one at a time
+type articleWriter interface {
+	Write(article *models.Article) error
+	Close() error
+}
+
+func newArticleWriter(w io.Writer, format string) (articleWriter, error) {
+	switch format {
+	case FORMAT_MARKDOWN:
+		return markdownWriter{zip.NewWriter(w)}, nil
+	case FORMAT_JSONL:
+		bw := bufio.NewWriter(w)
+		return jsonlWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	}
+
+	return newXlsxWriter(w)
+}
+
+// markdownWriter writes a zip of Markdown files with front matter
+type markdownWriter struct {
+	zw *zip.Writer
+}
+
+func (m markdownWriter) Write(article *models.Article) error {
+	matter := markdownMatter{
+		Title:       article.Title,
+		Description: article.Desc,
+		Date:        formatTime(article.CreatedOn),
+		Author:      article.CreatedBy,
+		Cover:       article.CoverImageUrl,
+		Draft:       article.State == 0,
+	}
+	if article.ModifiedOn > article.CreatedOn {
+		matter.Lastmod = formatTime(article.ModifiedOn)
+	}
+	if article.Tag.Name != "" {
+		matter.Tags = []string{article.Tag.Name}
+	}
+
+	data, err := frontmatter.Marshal(&matter, []byte(article.Content+"\n"))
+	if err != nil {
+		return err
+	}
+
+	w, err := m.zw.Create(markdownName(article))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func (m markdownWriter) Close() error {
+	return m.zw.Close()
+}
+
+type xlsxWriter struct {
+	*export.XlsxWriter
+}
+
+func newXlsxWriter(w io.Writer) (articleWriter, error) {
+	xw, err := export.NewXlsxWriter(w, "文章信息")
+	if err != nil {
+		return nil, err
+	}
+
+	titles := []string{"ID", "标题", "简述", "标签", "封面", "状态", "创建人", "创建时间", "修改人", "修改时间"}
+	if err := xw.WriteRow(titles); err != nil {
+		return nil, err
+	}
+
+	return xlsxWriter{xw}, nil
+}
+
+func (x xlsxWriter) Write(v *models.Article) error {
+	return x.WriteRow([]string{
+		strconv.Itoa(v.ID),
+		v.Title,
+		v.Desc,
+		v.Tag.Name,
+		v.CoverImageUrl,
+		strconv.Itoa(v.State),
+		v.CreatedBy,
+		strconv.Itoa(v.CreatedOn),
+		v.ModifiedBy,
+		strconv.Itoa(v.ModifiedOn),
+	})
+}
+
+// jsonlWriter writes one JSON object per line
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (j jsonlWriter) Write(article *models.Article) error {
+	return j.enc.Encode(article)
+}
+
+func (j jsonlWriter) Close() error {
+	return j.w.Flush()
+}
+
+// markdownName names an exported article by its ID and, when the title has any
+// ASCII letters or digits, a slug of it
+func markdownName(article *models.Article) string {
+	var slug []rune
+	dash := false
+	for _, r := range strings.ToLower(article.Title) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			slug = append(slug, r)
+			dash = false
+		} else if !dash && len(slug) > 0 {
+			slug = append(slug, '-')
+			dash = true
+		}
+	}
+
+	name := strconv.Itoa(article.ID)
+	if s := strings.Trim(string(slug), "-"); s != "" {
+		name += "-" + s
+	}
+
+	return name + ".md"
+}
+
+func formatTime(unix int) string {
+	return time.Unix(int64(unix), 0).Format(time.RFC3339)
+}
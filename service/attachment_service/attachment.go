@@ -0,0 +1,149 @@
+package attachment_service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+)
+
+// Attachment is a file to attach to an article, or the attachment to look up
+// or delete
+type Attachment struct {
+	ID           int
+	ArticleID    int
+	OriginalName string
+	Owner        string
+	CreatedBy    string
+}
+
+// GetAttachmentUrl get the address an attachment is downloaded from
+func GetAttachmentUrl(id int) string {
+	return setting.AppSetting.PrefixUrl + "/attachments/" + strconv.Itoa(id)
+}
+
+// Add checks the file read from r against the policy of its type and stores
+// it under its content hash. The file goes through a temporary file, as
+// attachments may be too large to hold in memory
+func (a *Attachment) Add(r io.Reader) (*models.Attachment, error) {
+	ext := path.Ext(a.OriginalName)
+	policy := upload.GetAttachmentPolicy(ext)
+	if policy == nil {
+		return nil, upload.ErrFileType
+	}
+
+	tmp, err := ioutil.TempFile("", "attachment-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(tmp, io.TeeReader(io.LimitReader(r, int64(policy.MaxSize)+1), h))
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	contentType, err := policy.Check(head[:n], size)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	name := upload.GetHashName(hash, ext)
+	if !storage.Exists(storage.Attachments, name) {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := storage.Attachments.Put(name, tmp); err != nil {
+			return nil, err
+		}
+	}
+
+	attachment := &models.Attachment{
+		ArticleID:    a.ArticleID,
+		Hash:         hash,
+		Name:         name,
+		OriginalName: path.Base(a.OriginalName),
+		Type:         policy.Type,
+		ContentType:  contentType,
+		Size:         int(size),
+		Owner:        a.Owner,
+		CreatedBy:    a.CreatedBy,
+	}
+	if err := models.AddAttachment(attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (a *Attachment) Get() (*models.Attachment, error) {
+	return models.GetAttachment(a.ID)
+}
+
+func (a *Attachment) GetAll() ([]*models.Attachment, error) {
+	return models.GetAttachments(a.ArticleID)
+}
+
+func (a *Attachment) ExistByID() (bool, error) {
+	return models.ExistAttachmentByID(a.ID)
+}
+
+// GetPublished returns the attachment when its article is published, or nil,
+// as drafts keep their attachments to themselves
+func (a *Attachment) GetPublished() (*models.Attachment, error) {
+	attachment, err := models.GetAttachment(a.ID)
+	if err != nil || attachment.ID == 0 {
+		return nil, err
+	}
+
+	article, err := models.GetArticle(attachment.ArticleID)
+	if err != nil || article.ID == 0 || article.State != 1 {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// CountDownload counts a download of the attachment
+func (a *Attachment) CountDownload() error {
+	return models.AddAttachmentDownload(a.ID)
+}
+
+// Delete removes an attachment, and its stored file unless another attachment
+// has the same content
+func (a *Attachment) Delete() error {
+	attachment, err := models.GetAttachment(a.ID)
+	if err != nil {
+		return err
+	}
+	if err := models.DeleteAttachment(a.ID); err != nil {
+		return err
+	}
+
+	used, err := models.ExistAttachmentByName(attachment.Name)
+	if err != nil || used {
+		return err
+	}
+	if err := storage.Attachments.Delete(attachment.Name); err != nil {
+		logging.Warn("attachment_service.Delete", attachment.Name, "err:", err)
+	}
+
+	return nil
+}
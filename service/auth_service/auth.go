@@ -10,3 +10,8 @@ type Auth struct {
 func (a *Auth) Check() (bool, error) {
 	return models.CheckAuth(a.Username, a.Password)
 }
+
+// GetRole returns the role of the account, deciding its upload quota
+func (a *Auth) GetRole() (string, error) {
+	return models.GetAuthRole(a.Username)
+}
@@ -0,0 +1,309 @@
+package backup_service
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/export"
+	"github.com/EDDYCJY/go-gin-example/pkg/file"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+// VERSION is the layout of the archives written here. Restore reads archives up
+// to this version
+const VERSION = 1
+
+// An archive holds, next to its manifest:
+//
+//	tables/<table>.jsonl  every row of the table, one JSON object per line
+//	images/<name>         the uploaded images
+//	attachments/<name>    the files attached to articles
+//	settings/app.ini      the configuration the backup was taken with, its
+//	                      passwords and secrets blanked
+const (
+	MANIFEST_NAME   = "manifest.json"
+	TABLES_DIR      = "tables/"
+	IMAGES_DIR      = "images/"
+	ATTACHMENTS_DIR = "attachments/"
+	SETTINGS_NAME   = "settings/app.ini"
+
+	TABLE_EXT = ".jsonl"
+)
+
+// settingsFile is where setting.Setup reads the configuration from
+const settingsFile = "conf/app.ini"
+
+var (
+	ErrInvalidArchive     = errors.New("not a backup archive")
+	ErrUnsupportedVersion = errors.New("backup archive was written by a newer version")
+	ErrCorruptArchive     = errors.New("backup archive is corrupt")
+	ErrDatabaseNotEmpty   = errors.New("database already holds data")
+)
+
+// Manifest describes an archive and the checksum of every other file in it
+type Manifest struct {
+	Version     int            `json:"version"`
+	CreatedOn   int64          `json:"created_on"`
+	Tables      map[string]int `json:"tables"`
+	Images      int            `json:"images"`
+	Attachments int            `json:"attachments"`
+	Files       []File         `json:"files"`
+}
+
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// Backup writes the whole site into a zip archive, kept in Dir on local disk or,
+// when Dir is empty, stored among the export files
+type Backup struct {
+	Dir string
+	// Accounts also writes the accounts, passwords included, into the archive
+	Accounts bool
+}
+
+// GetBackupFullPath get the full save path of backups
+func GetBackupFullPath() string {
+	return setting.AppSetting.RuntimeRootPath + setting.AppSetting.BackupSavePath
+}
+
+// Run writes the archive and returns its file name, which carries the id of
+// the job when there is one. progress may be nil
+func (b *Backup) Run(id string, progress export.ProgressFunc) (string, error) {
+	filename := export.GetFileName("backup", id, export.ZIP_EXT)
+	if b.Dir == "" {
+		err := export.Save(filename, func(w io.Writer) error {
+			return b.Write(w, progress)
+		})
+		if err != nil {
+			return "", err
+		}
+		return filename, nil
+	}
+
+	if err := file.IsNotExistMkDir(b.Dir); err != nil {
+		return "", err
+	}
+	src := filepath.Join(b.Dir, filename)
+
+	f, err := os.Create(src)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := b.Write(f, progress); err != nil {
+		f.Close()
+		os.Remove(src)
+		return "", err
+	}
+
+	return filename, f.Close()
+}
+
+// Write writes the archive to w. progress may be nil
+func (b *Backup) Write(w io.Writer, progress export.ProgressFunc) error {
+	if progress == nil {
+		progress = func(done, total int) {}
+	}
+
+	images, err := storage.Images.List("")
+	if err != nil {
+		return err
+	}
+	attachments, err := storage.Attachments.List("")
+	if err != nil {
+		return err
+	}
+
+	manifest := Manifest{
+		Version:     VERSION,
+		CreatedOn:   time.Now().Unix(),
+		Tables:      make(map[string]int),
+		Images:      len(images),
+		Attachments: len(attachments),
+	}
+
+	total := len(images) + len(attachments)
+	for _, table := range getTables(b.Accounts) {
+		if !models.ExistTable(table) {
+			continue
+		}
+		count, err := models.CountRows(table)
+		if err != nil {
+			return err
+		}
+		manifest.Tables[table] = count
+		total += count
+	}
+
+	aw := &archiveWriter{zw: zip.NewWriter(w), manifest: &manifest}
+	done := 0
+	step := func() {
+		done++
+		if done > total {
+			total = done
+		}
+		progress(done, total)
+	}
+
+	if err := aw.writeTables(step); err != nil {
+		return err
+	}
+	if err := aw.writeFiles(IMAGES_DIR, storage.Images, images, step); err != nil {
+		return err
+	}
+	if err := aw.writeFiles(ATTACHMENTS_DIR, storage.Attachments, attachments, step); err != nil {
+		return err
+	}
+	if err := aw.writeSettings(); err != nil {
+		return err
+	}
+
+	return aw.writeManifest()
+}
+
+type archiveWriter struct {
+	zw       *zip.Writer
+	manifest *Manifest
+}
+
+// create starts a file of the archive, recording its size and checksum in the
+// manifest once done is called
+func (w *archiveWriter) create(name string) (io.Writer, func(), error) {
+	fw, err := w.zw.Create(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	h := sha256.New()
+	counter := &countWriter{Hash: h}
+	done := func() {
+		w.manifest.Files = append(w.manifest.Files, File{
+			Path:   name,
+			Size:   counter.n,
+			Sha256: hex.EncodeToString(h.Sum(nil)),
+		})
+	}
+
+	return io.MultiWriter(fw, counter), done, nil
+}
+
+func (w *archiveWriter) writeTables(step func()) error {
+	for _, table := range getTables(true) {
+		if _, ok := w.manifest.Tables[table]; !ok {
+			continue
+		}
+
+		fw, done, err := w.create(TABLES_DIR + table + TABLE_EXT)
+		if err != nil {
+			return err
+		}
+		bw := bufio.NewWriter(fw)
+		enc := json.NewEncoder(bw)
+		err = models.EachRow(table, func(row map[string]interface{}) error {
+			step()
+			return enc.Encode(row)
+		})
+		if err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		done()
+	}
+
+	return nil
+}
+
+// writeFiles copies the files of a storage into a directory of the archive
+func (w *archiveWriter) writeFiles(dir string, s storage.Storage, files []storage.Info, step func()) error {
+	for _, f := range files {
+		fw, done, err := w.create(dir + f.Name)
+		if err != nil {
+			return err
+		}
+
+		src, err := s.Get(f.Name)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fw, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+
+		done()
+		step()
+	}
+
+	return nil
+}
+
+func (w *archiveWriter) writeSettings() error {
+	data, err := redactSettings(settingsFile)
+	if err != nil {
+		return err
+	}
+
+	fw, done, err := w.create(SETTINGS_NAME)
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return err
+	}
+	done()
+
+	return nil
+}
+
+func (w *archiveWriter) writeManifest() error {
+	fw, err := w.zw.Create(MANIFEST_NAME)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(fw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(w.manifest); err != nil {
+		return err
+	}
+
+	return w.zw.Close()
+}
+
+// getTables get the tables an archive may hold, in the order they are restored
+func getTables(accounts bool) []string {
+	tables := append([]string{}, models.BackupTables...)
+	if accounts {
+		tables = append(tables, models.AccountTables...)
+	}
+
+	return tables
+}
+
+type countWriter struct {
+	hash.Hash
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return c.Hash.Write(p)
+}
@@ -0,0 +1,349 @@
+package backup_service
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+)
+
+// Restore loads an archive written by Backup back into the database and the image storage
+type Restore struct {
+	// Overwrite replaces the rows of the tables in the archive, otherwise they must be empty
+	Overwrite bool
+	// Settings also puts the backed up configuration in place of conf/app.ini,
+	// keeping the current one as conf/app.ini.bak
+	Settings bool
+	// Accounts replaces the accounts with those of the archive, when it holds them
+	Accounts bool
+}
+
+type RestoreReport struct {
+	Version     int            `json:"version"`
+	CreatedOn   int64          `json:"created_on"`
+	Tables      map[string]int `json:"tables"`
+	Created     []string       `json:"created"`
+	Images      int            `json:"images"`
+	Attachments int            `json:"attachments"`
+	Settings    bool           `json:"settings"`
+}
+
+// archive is a validated backup archive
+type archive struct {
+	manifest *Manifest
+	files    map[string]*zip.File
+}
+
+// Verify checks that r holds a backup archive this version can read and that
+// every file in it matches its checksum
+func Verify(r io.ReaderAt, size int64) (*Manifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+
+	a, err := open(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.manifest, nil
+}
+
+// Run verifies the archive and restores it. Nothing is written unless the whole
+// archive is valid, and the tables are loaded in a single transaction
+func (r *Restore) Run(ra io.ReaderAt, size int64) (*RestoreReport, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+
+	a, err := open(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RestoreReport{
+		Version:   a.manifest.Version,
+		CreatedOn: a.manifest.CreatedOn,
+		Tables:    make(map[string]int),
+	}
+
+	if !r.Overwrite {
+		for _, table := range models.BackupTables {
+			if _, ok := a.manifest.Tables[table]; !ok || !models.ExistTable(table) {
+				continue
+			}
+			count, err := models.CountRows(table)
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				return nil, ErrDatabaseNotEmpty
+			}
+		}
+	}
+
+	if report.Created, err = a.createTables(r.Accounts); err != nil {
+		return nil, err
+	}
+	if err := a.loadTables(r.Overwrite, r.Accounts, report); err != nil {
+		return nil, err
+	}
+	clearCache()
+
+	if report.Images, err = a.restoreFiles(IMAGES_DIR, storage.Images); err != nil {
+		return report, err
+	}
+	if report.Attachments, err = a.restoreFiles(ATTACHMENTS_DIR, storage.Attachments); err != nil {
+		return report, err
+	}
+
+	if r.Settings {
+		if err := a.restoreSettings(); err != nil {
+			return report, err
+		}
+		report.Settings = true
+	}
+
+	return report, nil
+}
+
+func open(zr *zip.Reader) (*archive, error) {
+	a := &archive{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, "/") {
+			a.files[f.Name] = f
+		}
+	}
+
+	mf, ok := a.files[MANIFEST_NAME]
+	if !ok {
+		return nil, ErrInvalidArchive
+	}
+	rc, err := mf.Open()
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+	err = json.NewDecoder(rc).Decode(&a.manifest)
+	rc.Close()
+	if err != nil || a.manifest.Version < 1 {
+		return nil, ErrInvalidArchive
+	}
+	if a.manifest.Version > VERSION {
+		return nil, ErrUnsupportedVersion
+	}
+
+	listed := make(map[string]bool, len(a.manifest.Files))
+	for _, f := range a.manifest.Files {
+		if err := a.check(f); err != nil {
+			return nil, err
+		}
+		listed[f.Path] = true
+	}
+	for name := range a.files {
+		if name != MANIFEST_NAME && !listed[name] {
+			return nil, corrupt("%s is not in the manifest", name)
+		}
+	}
+
+	for _, table := range getTables(true) {
+		if _, ok := a.manifest.Tables[table]; ok && !listed[TABLES_DIR+table+TABLE_EXT] {
+			return nil, corrupt("table %s is incomplete", table)
+		}
+	}
+
+	return a, nil
+}
+
+// check compares a file of the archive with its manifest entry
+func (a *archive) check(f File) error {
+	if f.Path != path.Clean(f.Path) || path.IsAbs(f.Path) || strings.HasPrefix(f.Path, "../") {
+		return corrupt("invalid path %s", f.Path)
+	}
+
+	zf, ok := a.files[f.Path]
+	if !ok {
+		return corrupt("%s is missing", f.Path)
+	}
+
+	rc, err := zf.Open()
+	if err != nil {
+		return corrupt("%s: %v", f.Path, err)
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, rc)
+	if err != nil {
+		return corrupt("%s: %v", f.Path, err)
+	}
+	if n != f.Size || hex.EncodeToString(h.Sum(nil)) != f.Sha256 {
+		return corrupt("%s does not match its checksum", f.Path)
+	}
+
+	return nil
+}
+
+func (a *archive) read(name string) ([]byte, error) {
+	rc, err := a.files[name].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+// createTables creates the tables of the archive missing from the database
+func (a *archive) createTables(accounts bool) ([]string, error) {
+	var created []string
+	for _, table := range getTables(accounts) {
+		if _, ok := a.manifest.Tables[table]; !ok || models.ExistTable(table) {
+			continue
+		}
+
+		if err := models.CreateTable(table); err != nil {
+			return created, err
+		}
+		created = append(created, table)
+	}
+
+	return created, nil
+}
+
+// loadTables loads the tables of the archive, the accounts only when asked and
+// then always in place of the current ones
+func (a *archive) loadTables(overwrite, accounts bool, report *RestoreReport) error {
+	restore := models.BeginRestore()
+	for _, table := range getTables(accounts) {
+		if _, ok := a.manifest.Tables[table]; !ok {
+			continue
+		}
+
+		count, err := a.loadTable(restore, table, overwrite || isAccountTable(table))
+		if err != nil {
+			restore.Rollback()
+			return err
+		}
+		report.Tables[table] = count
+	}
+
+	return restore.Commit()
+}
+
+func (a *archive) loadTable(restore *models.TableRestore, table string, overwrite bool) (int, error) {
+	if overwrite {
+		if err := restore.Empty(table); err != nil {
+			return 0, err
+		}
+	}
+
+	rc, err := a.files[TABLES_DIR+table+TABLE_EXT].Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	dec := json.NewDecoder(rc)
+	dec.UseNumber()
+
+	count := 0
+	for {
+		var row map[string]interface{}
+		err := dec.Decode(&row)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, corrupt("%s: %v", table, err)
+		}
+
+		if err := restore.Insert(table, row); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func isAccountTable(table string) bool {
+	for _, t := range models.AccountTables {
+		if t == table {
+			return true
+		}
+	}
+
+	return false
+}
+
+// restoreFiles puts the files of a directory of the archive back into a storage
+func (a *archive) restoreFiles(dir string, s storage.Storage) (int, error) {
+	count := 0
+	for _, f := range a.manifest.Files {
+		if !strings.HasPrefix(f.Path, dir) {
+			continue
+		}
+
+		rc, err := a.files[f.Path].Open()
+		if err != nil {
+			return count, err
+		}
+		err = s.Put(strings.TrimPrefix(f.Path, dir), rc)
+		rc.Close()
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func (a *archive) restoreSettings() error {
+	if _, ok := a.files[SETTINGS_NAME]; !ok {
+		return corrupt("%s is missing", SETTINGS_NAME)
+	}
+
+	data, err := a.read(SETTINGS_NAME)
+	if err != nil {
+		return err
+	}
+	if data, err = unredactSettings(data, settingsFile); err != nil {
+		return err
+	}
+
+	if current, err := ioutil.ReadFile(settingsFile); err == nil {
+		if err := ioutil.WriteFile(settingsFile+".bak", current, 0600); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(settingsFile, data, 0600)
+}
+
+// clearCache drops the cached articles, tags and the pages built from them,
+// which no longer match the restored tables
+func clearCache() {
+	for _, key := range []string{e.CACHE_ARTICLE, e.CACHE_TAG, e.CACHE_FEED, e.CACHE_SITEMAP} {
+		if err := gredis.LikeDeletes(key); err != nil {
+			logging.Warn(err)
+		}
+	}
+}
+
+func corrupt(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrCorruptArchive}, args...)...)
+}
@@ -0,0 +1,74 @@
+package backup_service
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/go-ini/ini"
+)
+
+// secretKeys are the keys of the configuration, in any section, kept out of
+// archives: the database and Redis passwords, the JWT secret and the S3 keys
+var secretKeys = map[string]bool{
+	"Password":  true,
+	"JwtSecret": true,
+	"AccessKey": true,
+	"SecretKey": true,
+}
+
+// redactSettings reads the configuration file with its secrets blanked
+func redactSettings(name string) ([]byte, error) {
+	cfg, err := ini.Load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, section := range cfg.Sections() {
+		for _, key := range section.Keys() {
+			if secretKeys[key.Name()] {
+				key.SetValue("")
+			}
+		}
+	}
+
+	return writeSettings(cfg)
+}
+
+// unredactSettings fills the secrets blanked in a backed up configuration with
+// those of the configuration file in use, when it has them
+func unredactSettings(data []byte, name string) ([]byte, error) {
+	cfg, err := ini.Load(data)
+	if err != nil {
+		return nil, corrupt("%s: %v", SETTINGS_NAME, err)
+	}
+
+	current, err := ini.Load(name)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, section := range cfg.Sections() {
+		for _, key := range section.Keys() {
+			if !secretKeys[key.Name()] || key.Value() != "" {
+				continue
+			}
+			if s, err := current.GetSection(section.Name()); err == nil && s.HasKey(key.Name()) {
+				key.SetValue(s.Key(key.Name()).Value())
+			}
+		}
+	}
+
+	return writeSettings(cfg)
+}
+
+func writeSettings(cfg *ini.File) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := cfg.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
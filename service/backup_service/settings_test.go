@@ -0,0 +1,64 @@
+package backup_service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-ini/ini"
+)
+
+func TestRedactSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.ini")
+	current := "[app]\nJwtSecret = 233\nPageSize = 10\n\n[database]\nUser = bloger\nPassword = secret\n\n[storage]\nAccessKey = key\nSecretKey = s3secret\n"
+	if err := ioutil.WriteFile(name, []byte(current), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	redacted, err := redactSettings(name)
+	if err != nil {
+		t.Fatalf("redactSettings() err = %v", err)
+	}
+
+	tests := []struct {
+		section, key  string
+		redacted, got string
+	}{
+		{"app", "JwtSecret", "", "233"},
+		{"app", "PageSize", "10", "10"},
+		{"database", "User", "bloger", "bloger"},
+		{"database", "Password", "", "secret"},
+		{"storage", "AccessKey", "", "key"},
+		{"storage", "SecretKey", "", "s3secret"},
+	}
+
+	cfg, err := ini.Load(redacted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if got := cfg.Section(tt.section).Key(tt.key).Value(); got != tt.redacted {
+			t.Errorf("redacted %s.%s = %q, want %q", tt.section, tt.key, got, tt.redacted)
+		}
+	}
+
+	restored, err := unredactSettings(redacted, name)
+	if err != nil {
+		t.Fatalf("unredactSettings() err = %v", err)
+	}
+	if cfg, err = ini.Load(restored); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if got := cfg.Section(tt.section).Key(tt.key).Value(); got != tt.got {
+			t.Errorf("restored %s.%s = %q, want %q", tt.section, tt.key, got, tt.got)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package cache_service
+
+import (
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+)
+
+type ExportJob struct {
+	ID string
+}
+
+func (j *ExportJob) GetExportJobKey() string {
+	return strings.Join([]string{e.CACHE_EXPORT_JOB, j.ID}, "_")
+}
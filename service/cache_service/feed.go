@@ -0,0 +1,31 @@
+package cache_service
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+)
+
+type Feed struct {
+	Format string
+	TagID  int
+	Author string
+}
+
+func (f *Feed) GetFeedKey() string {
+	keys := []string{
+		e.CACHE_FEED,
+		f.Format,
+	}
+
+	if f.TagID > 0 {
+		keys = append(keys, "TAG", strconv.Itoa(f.TagID))
+	}
+	if f.Author != "" {
+		keys = append(keys, "AUTHOR", util.EncodeMD5(f.Author))
+	}
+
+	return strings.Join(keys, "_")
+}
@@ -0,0 +1,20 @@
+package cache_service
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+)
+
+type Reaction struct {
+	ArticleID int
+}
+
+func (r *Reaction) GetReactionKey() string {
+	return e.CACHE_ARTICLE_REACTION + "_" + strconv.Itoa(r.ArticleID)
+}
+
+func (r *Reaction) GetDirtyKey() string {
+	return strings.Join([]string{e.CACHE_ARTICLE_REACTION, "DIRTY"}, "_")
+}
@@ -0,0 +1,24 @@
+package cache_service
+
+import (
+	"strconv"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+)
+
+type Related struct {
+	ArticleID int
+	Viewer    string
+}
+
+func (r *Related) GetRelatedKey() string {
+	return e.CACHE_ARTICLE_RELATED + "_" + strconv.Itoa(r.ArticleID)
+}
+
+func (r *Related) GetCoViewKey() string {
+	return e.CACHE_ARTICLE_COVIEW + "_" + strconv.Itoa(r.ArticleID)
+}
+
+func (r *Related) GetViewsKey() string {
+	return e.CACHE_ARTICLE_VIEWS + "_" + r.Viewer
+}
@@ -0,0 +1,13 @@
+package cache_service
+
+import (
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+)
+
+type Sitemap struct{}
+
+func (s *Sitemap) GetDirtyKey() string {
+	return strings.Join([]string{e.CACHE_SITEMAP, "DIRTY"}, "_")
+}
@@ -0,0 +1,25 @@
+package cache_service
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+)
+
+type UploadRate struct {
+	Owner  string
+	Window int64
+}
+
+func (u *UploadRate) GetUploadRateKey() string {
+	return strings.Join([]string{e.CACHE_UPLOAD_RATE, u.Owner, strconv.FormatInt(u.Window, 10)}, "_")
+}
+
+type UploadReserved struct {
+	Owner string
+}
+
+func (u *UploadReserved) GetUploadReservedKey() string {
+	return e.CACHE_UPLOAD_RESERVED + "_" + u.Owner
+}
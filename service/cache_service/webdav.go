@@ -0,0 +1,17 @@
+package cache_service
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/e"
+)
+
+type WebDAVAuthFailure struct {
+	IP     string
+	Window int64
+}
+
+func (w *WebDAVAuthFailure) GetWebDAVAuthFailureKey() string {
+	return strings.Join([]string{e.CACHE_WEBDAV_AUTH_FAILURE, w.IP, strconv.FormatInt(w.Window, 10)}, "_")
+}
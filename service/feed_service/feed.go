@@ -0,0 +1,143 @@
+package feed_service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/feed"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/link"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+type Feed struct {
+	Format string
+	TagID  int
+	Author string
+}
+
+// Rendered is a feed document together with its cache validators
+type Rendered struct {
+	Body         []byte
+	ContentType  string
+	ETag         string
+	LastModified int64
+}
+
+// Render returns the feed document, rendering it only when the cached copy has expired
+func (f *Feed) Render() (*Rendered, error) {
+	var cacheRendered *Rendered
+
+	cache := cache_service.Feed{Format: f.Format, TagID: f.TagID, Author: f.Author}
+	key := cache.GetFeedKey()
+	if gredis.Exists(key) {
+		data, err := gredis.Get(key)
+		if err != nil {
+			logging.Info(err)
+		} else {
+			json.Unmarshal(data, &cacheRendered)
+			return cacheRendered, nil
+		}
+	}
+
+	doc, err := f.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := doc.Render(f.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := &Rendered{
+		Body:         body,
+		ContentType:  feed.ContentType(f.Format),
+		ETag:         `"` + util.EncodeMD5(string(body)) + `"`,
+		LastModified: doc.Updated.Unix(),
+	}
+
+	gredis.Set(key, rendered, setting.FeedSetting.CacheExpire)
+	return rendered, nil
+}
+
+// Build collects the latest published articles of the feed
+func (f *Feed) Build() (*feed.Feed, error) {
+	maps := map[string]interface{}{
+		"deleted_on": 0,
+		"state":      1,
+	}
+
+	doc := &feed.Feed{
+		Title:       setting.FeedSetting.Title,
+		Link:        link.GetHomeFullUrl(),
+		FeedLink:    link.GetFeedFullUrl(f.Format),
+		Description: setting.FeedSetting.Description,
+	}
+
+	if f.TagID > 0 {
+		tag, err := models.GetTag(f.TagID)
+		if err != nil {
+			return nil, err
+		}
+
+		maps["tag_id"] = f.TagID
+		doc.Title += " - " + tag.Name
+		doc.Link = link.GetTagFullUrl(f.TagID)
+		doc.FeedLink = link.GetTagFeedFullUrl(f.TagID, f.Format)
+	}
+	if f.Author != "" {
+		maps["created_by"] = f.Author
+		doc.Title += " - " + f.Author
+		doc.Author = f.Author
+		doc.Link = link.GetAuthorFullUrl(f.Author)
+		doc.FeedLink = link.GetAuthorFeedFullUrl(f.Author, f.Format)
+	}
+
+	articles, err := models.GetLatestArticles(0, setting.FeedSetting.Limit, maps)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range articles {
+		item := NewItem(a)
+		doc.Items = append(doc.Items, item)
+		if item.Updated.After(doc.Updated) {
+			doc.Updated = item.Updated
+		}
+	}
+	if doc.Updated.IsZero() {
+		doc.Updated = time.Unix(0, 0)
+	}
+
+	return doc, nil
+}
+
+// NewItem describes an article as a feed entry
+func NewItem(a *models.Article) *feed.Item {
+	updated := a.ModifiedOn
+	if updated < a.CreatedOn {
+		updated = a.CreatedOn
+	}
+
+	item := &feed.Item{
+		ID:          link.GetArticleFullUrl(a.ID),
+		Title:       a.Title,
+		Link:        link.GetArticleFullUrl(a.ID),
+		Description: a.Desc,
+		Content:     a.Content,
+		Author:      a.CreatedBy,
+		Image:       a.CoverImageUrl,
+		Published:   time.Unix(int64(a.CreatedOn), 0),
+		Updated:     time.Unix(int64(updated), 0),
+	}
+	if a.Tag.Name != "" {
+		item.Categories = []string{a.Tag.Name}
+	}
+
+	return item
+}
@@ -0,0 +1,258 @@
+package gc_service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/media_service"
+)
+
+// The areas collected, which also name their directory in the quarantine
+const (
+	AREA_IMAGES  = "images"
+	AREA_POSTERS = "posters"
+)
+
+// scanBatchSize is how many articles are read at a time while looking for image links
+const scanBatchSize = 200
+
+var ErrNotQuarantined = errors.New("file is not in quarantine")
+
+// File is a file the collector moved or removed, by its path in the quarantine
+type File struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"`
+}
+
+type Report struct {
+	DryRun      bool     `json:"dry_run"`
+	Scanned     int      `json:"scanned"`
+	Referenced  int      `json:"referenced"`
+	Quarantined []File   `json:"quarantined"`
+	Purged      []File   `json:"purged"`
+	Failed      []string `json:"failed"`
+	Bytes       int64    `json:"bytes"`
+}
+
+// Collector moves the uploads nothing uses into the quarantine once they are
+// older than the grace period, and purges what has been in quarantine longer
+// than the retention period.
+//
+// An image is in use while it is in the media library, or while an article,
+// deleted or not, links to it from its content or cover. The collector never
+// takes media out of the library: an image only goes once its media is
+// deleted and nothing links to it. Posters and QR codes are made again on request, so only
+// the poster background is kept. An article saved while the collector runs
+// may link an image just quarantined; Restore puts it back
+type Collector struct {
+	// DryRun only reports what would be moved and purged
+	DryRun bool
+}
+
+// Collect runs the collector, as scheduled
+func Collect() error {
+	collector := Collector{}
+	report, err := collector.Run()
+	if err != nil {
+		return err
+	}
+
+	if len(report.Quarantined) > 0 || len(report.Purged) > 0 || len(report.Failed) > 0 {
+		logging.Info("gc_service.Collect quarantined:", len(report.Quarantined),
+			"purged:", len(report.Purged), "failed:", len(report.Failed))
+	}
+	return nil
+}
+
+func (c *Collector) Run() (*Report, error) {
+	report := &Report{DryRun: c.DryRun}
+
+	// a failed scan must not make every image look unused
+	used, err := usedImages()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(-setting.GcSetting.GracePeriod)
+	images, err := storage.Images.List("")
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range images {
+		report.Scanned++
+		if used[info.Name] {
+			report.Referenced++
+			continue
+		}
+		if info.ModTime.After(deadline) {
+			continue
+		}
+		c.quarantine(report, AREA_IMAGES, info)
+	}
+
+	posters, err := storage.Posters.List("")
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range posters {
+		report.Scanned++
+		if info.Name == article_service.GetPosterBgName() {
+			report.Referenced++
+			continue
+		}
+		if info.ModTime.After(deadline) {
+			continue
+		}
+		c.quarantine(report, AREA_POSTERS, info)
+	}
+
+	if err := c.purge(report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (c *Collector) quarantine(report *Report, area string, info storage.Info) {
+	path := area + "/" + info.Name
+	if !c.DryRun {
+		if err := move(getArea(area), info.Name, storage.Quarantine, path); err != nil {
+			logging.Warn("gc_service quarantine", path, "err:", err)
+			report.Failed = append(report.Failed, path+": "+err.Error())
+			return
+		}
+		if area == AREA_IMAGES {
+			upload.RemoveVariants(info.Name)
+		}
+	}
+
+	report.Quarantined = append(report.Quarantined, newFile(path, info))
+	report.Bytes += info.Size
+}
+
+// purge removes the files quarantined longer than the retention period
+func (c *Collector) purge(report *Report) error {
+	files, err := storage.Quarantine.List("")
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(-setting.GcSetting.Retention)
+	for _, info := range files {
+		if info.ModTime.After(deadline) {
+			continue
+		}
+		if !c.DryRun {
+			if err := storage.Quarantine.Delete(info.Name); err != nil {
+				logging.Warn("gc_service purge", info.Name, "err:", err)
+				report.Failed = append(report.Failed, info.Name+": "+err.Error())
+				continue
+			}
+		}
+		report.Purged = append(report.Purged, newFile(info.Name, info))
+	}
+
+	return nil
+}
+
+// Restore moves a quarantined file back, by its path in the quarantine, and
+// puts the media of an image back into the library, as earlier versions of the
+// collector took it out
+func Restore(path string) error {
+	i := strings.Index(path, "/")
+	if i < 0 || getArea(path[:i]) == nil {
+		return ErrNotQuarantined
+	}
+	area, name := path[:i], path[i+1:]
+
+	if !storage.Exists(storage.Quarantine, path) {
+		return ErrNotQuarantined
+	}
+	if err := move(storage.Quarantine, path, getArea(area), name); err != nil {
+		return err
+	}
+
+	if area == AREA_IMAGES {
+		media, err := models.GetMediaByName(name)
+		if err != nil {
+			return err
+		}
+		if media != nil && media.DeletedOn > 0 {
+			return models.RestoreMedia(media.ID)
+		}
+	}
+
+	return nil
+}
+
+func getArea(area string) storage.Storage {
+	switch area {
+	case AREA_IMAGES:
+		return storage.Images
+	case AREA_POSTERS:
+		return storage.Posters
+	}
+
+	return nil
+}
+
+// move copies a file to another storage and removes the original once the copy is whole
+func move(src storage.Storage, srcName string, dst storage.Storage, dstName string) error {
+	r, err := src.Get(srcName)
+	if err != nil {
+		return err
+	}
+	err = dst.Put(dstName, r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	return src.Delete(srcName)
+}
+
+// usedImages collects the names of the images in the media library and of
+// those linked from every article
+func usedImages() (map[string]bool, error) {
+	names, err := models.GetMediaNames()
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool, len(names))
+	for _, name := range names {
+		used[name] = true
+	}
+
+	lastID := 0
+	for {
+		articles, err := models.GetArticlesAfter(lastID, scanBatchSize, map[string]interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		if len(articles) == 0 {
+			return used, nil
+		}
+
+		for _, article := range articles {
+			for _, name := range media_service.FindImageNames(article.CoverImageUrl) {
+				used[name] = true
+			}
+			for _, name := range media_service.FindImageNames(article.Content) {
+				used[name] = true
+			}
+		}
+		lastID = articles[len(articles)-1].ID
+	}
+}
+
+func newFile(path string, info storage.Info) File {
+	return File{Path: path, Size: info.Size, ModTime: info.ModTime.Unix()}
+}
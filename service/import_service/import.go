@@ -0,0 +1,132 @@
+package import_service
+
+import (
+	"path"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/service/media_service"
+	"github.com/EDDYCJY/go-gin-example/service/tag_service"
+)
+
+const (
+	STATUS_IMPORTED = "imported"
+	STATUS_FAILED   = "failed"
+	STATUS_SKIPPED  = "skipped"
+	STATUS_DRY_RUN  = "dry-run"
+)
+
+// Result describes what happened to one source file
+type Result struct {
+	File      string   `json:"file"`
+	Status    string   `json:"status"`
+	ArticleID int      `json:"article_id,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	NewTags   []string `json:"new_tags,omitempty"`
+	Images    []string `json:"images,omitempty"`
+	Comments  int      `json:"comments,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Report lists the outcome of every file in an import
+type Report struct {
+	DryRun   bool      `json:"dry_run"`
+	Imported int       `json:"imported"`
+	Skipped  int       `json:"skipped"`
+	Failed   int       `json:"failed"`
+	Files    []*Result `json:"files"`
+}
+
+func (r *Report) add(result *Result) {
+	if result.Error != "" {
+		result.Status = STATUS_FAILED
+		r.Failed++
+	} else if result.Status == STATUS_SKIPPED {
+		r.Skipped++
+	} else if r.DryRun {
+		result.Status = STATUS_DRY_RUN
+	} else {
+		result.Status = STATUS_IMPORTED
+		r.Imported++
+	}
+
+	r.Files = append(r.Files, result)
+}
+
+// tagCache resolves tag names to IDs, creating the tags an import is missing
+type tagCache struct {
+	createdBy string
+	dryRun    bool
+	ids       map[string]int
+}
+
+func newTagCache(createdBy string, dryRun bool) *tagCache {
+	return &tagCache{createdBy: createdBy, dryRun: dryRun, ids: make(map[string]int)}
+}
+
+// ensure returns the ID of the named tag, creating it when missing. In a dry run
+// missing tags are only reported and get the ID 0
+func (c *tagCache) ensure(name string, result *Result) (int, error) {
+	if id, ok := c.ids[name]; ok {
+		return id, nil
+	}
+
+	tagService := tag_service.Tag{Name: name}
+	tag, err := tagService.GetByName()
+	if err != nil {
+		return 0, err
+	}
+	if tag != nil {
+		c.ids[name] = tag.ID
+		return tag.ID, nil
+	}
+
+	result.NewTags = append(result.NewTags, name)
+	if !c.dryRun {
+		tagService = tag_service.Tag{Name: name, State: 1, CreatedBy: c.createdBy}
+		if err := tagService.Add(); err != nil {
+			return 0, err
+		}
+	}
+
+	c.ids[name] = tagService.ID
+	return tagService.ID, nil
+}
+
+// ensureFirst returns the ID of the tag an imported post is filed under. An
+// article has a single tag, so only the first is used, and created when
+// missing, while the others are reported in a warning
+func (c *tagCache) ensureFirst(result *Result) (int, error) {
+	if len(result.Tags) > 1 {
+		result.Warnings = append(result.Warnings, "articles have a single tag, ignored: "+strings.Join(result.Tags[1:], ", "))
+	}
+
+	return c.ensure(result.Tags[0], result)
+}
+
+// saveImage stores an imported image as media under its content hash, so
+// importing the same image twice keeps one copy, and returns its URL
+func saveImage(name string, data []byte, createdBy string, dryRun bool) (string, error) {
+	data, err := upload.CleanImage(data, path.Ext(name))
+	if err != nil {
+		return "", err
+	}
+
+	if dryRun {
+		return upload.GetImageFullUrl(upload.GetHashName(upload.GetImageHash(data), path.Ext(name))), nil
+	}
+
+	mediaService := media_service.Media{
+		Data:         data,
+		OriginalName: path.Base(name),
+		CreatedBy:    createdBy,
+	}
+	media, _, err := mediaService.Save()
+	if err != nil {
+		return "", err
+	}
+
+	return upload.GetImageFullUrl(media.Name), nil
+}
@@ -0,0 +1,250 @@
+package import_service
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/frontmatter"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+)
+
+var (
+	markdownImage = regexp.MustCompile(`(!\[[^\]]*\]\()([^)\s]+)`)
+	htmlImage     = regexp.MustCompile(`(<img\b[^>]*?\bsrc=["'])([^"']+)`)
+	figureImage   = regexp.MustCompile(`(\{\{[<%]\s*figure\b[^}]*?\bsrc=["'])([^"']+)`)
+)
+
+// Markdown imports posts written as Markdown with YAML or TOML front matter,
+// as found in a Hugo or Jekyll repository
+type Markdown struct {
+	Source    Source
+	CreatedBy string
+	DryRun    bool
+
+	files  map[string]bool
+	tags   *tagCache
+	images map[string]string
+}
+
+// Run imports every Markdown file of the source. A Hugo tree is recognised by its
+// content/ directory, in which case only the posts below it are imported
+func (m *Markdown) Run() (*Report, error) {
+	m.files = make(map[string]bool)
+	m.tags = newTagCache(m.CreatedBy, m.DryRun)
+	m.images = make(map[string]string)
+
+	var posts []string
+	for _, name := range m.Source.Names() {
+		m.files[name] = true
+	}
+	hugo := m.hasContentDir()
+	for _, name := range m.Source.Names() {
+		if isPost(name, hugo) {
+			posts = append(posts, name)
+		}
+	}
+
+	report := &Report{DryRun: m.DryRun}
+	for _, name := range posts {
+		result := &Result{File: name}
+		if err := m.importFile(name, result); err != nil {
+			result.Error = err.Error()
+		}
+		report.add(result)
+	}
+
+	return report, nil
+}
+
+func (m *Markdown) importFile(name string, result *Result) error {
+	data, err := m.Source.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	matter, body, err := frontmatter.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	result.Title = matter.String("title")
+	if result.Title == "" {
+		return fmt.Errorf("front matter has no title")
+	}
+
+	result.Tags = matter.Strings("tags", "categories")
+	if len(result.Tags) == 0 && setting.ImportSetting.DefaultTag != "" {
+		result.Tags = []string{setting.ImportSetting.DefaultTag}
+	}
+	if len(result.Tags) == 0 {
+		return fmt.Errorf("front matter has no tags or categories")
+	}
+
+	content := m.rewriteImages(name, string(body), result)
+
+	cover := matter.String("cover", "image", "featured_image", "featuredImage")
+	if cover == "" {
+		cover = matter.Map("cover").String("image")
+	}
+	if cover != "" {
+		cover = m.resolveImage(name, cover, result)
+	}
+
+	tagID, err := m.tags.ensureFirst(result)
+	if err != nil {
+		return err
+	}
+
+	createdBy := matter.String("author", "authors")
+	if createdBy == "" {
+		createdBy = m.CreatedBy
+	}
+
+	state := 1
+	if matter.Bool("draft") {
+		state = 0
+	}
+
+	articleService := article_service.Article{
+		TagID:         tagID,
+		Title:         result.Title,
+		Desc:          matter.String("description", "desc", "summary"),
+		Content:       strings.TrimSpace(content),
+		CoverImageUrl: cover,
+		State:         state,
+		CreatedBy:     createdBy,
+	}
+	if date, ok := matter.Time("date", "publishDate"); ok {
+		articleService.CreatedOn = int(date.Unix())
+	}
+
+	if m.DryRun {
+		return nil
+	}
+	if err := articleService.Add(); err != nil {
+		return err
+	}
+
+	result.ArticleID = articleService.ID
+	return nil
+}
+
+// rewriteImages points the relative image links of a post at uploaded copies
+func (m *Markdown) rewriteImages(name, content string, result *Result) string {
+	for _, re := range []*regexp.Regexp{markdownImage, htmlImage, figureImage} {
+		content = re.ReplaceAllStringFunc(content, func(match string) string {
+			parts := re.FindStringSubmatch(match)
+			return parts[1] + m.resolveImage(name, parts[2], result)
+		})
+	}
+
+	return content
+}
+
+// resolveImage uploads the image a link of the post refers to and returns its URL.
+// Remote links, and local ones that cannot be found, are returned unchanged
+func (m *Markdown) resolveImage(post, link string, result *Result) string {
+	if isRemote(link) {
+		return link
+	}
+
+	target := m.findFile(post, link)
+	if target == "" {
+		result.Warnings = append(result.Warnings, "image not found: "+link)
+		return link
+	}
+
+	url, ok := m.images[target]
+	if ok {
+		result.Images = append(result.Images, target)
+		return url
+	}
+
+	data, err := m.Source.ReadFile(target)
+	if err == nil {
+		url, err = saveImage(target, data, m.CreatedBy, m.DryRun)
+	}
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("image %s: %v", link, err))
+		return link
+	}
+
+	m.images[target] = url
+	result.Images = append(result.Images, target)
+	return url
+}
+
+// findFile resolves a link relative to the post, or for a root-relative link
+// relative to the source root or the Hugo static/ directory
+func (m *Markdown) findFile(post, link string) string {
+	if i := strings.IndexAny(link, "?#"); i >= 0 {
+		link = link[:i]
+	}
+
+	var candidates []string
+	if strings.HasPrefix(link, "/") {
+		candidates = append(candidates, path.Clean(link[1:]), path.Clean("static"+link))
+	} else {
+		candidates = append(candidates, path.Join(path.Dir(post), link))
+	}
+
+	for _, candidate := range candidates {
+		if m.files[candidate] {
+			return candidate
+		}
+	}
+	// archives often wrap the tree in a top level directory
+	if strings.HasPrefix(link, "/") {
+		for _, candidate := range candidates {
+			for _, name := range m.Source.Names() {
+				if strings.HasSuffix(name, "/"+candidate) {
+					return name
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+func (m *Markdown) hasContentDir() bool {
+	for name := range m.files {
+		if strings.HasPrefix(name, "content/") || strings.Contains(name, "/content/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPost reports whether name is a Markdown post rather than a list page or readme
+func isPost(name string, hugo bool) bool {
+	ext := strings.ToLower(path.Ext(name))
+	if ext != ".md" && ext != ".markdown" {
+		return false
+	}
+
+	base := path.Base(name)
+	if strings.HasPrefix(base, "_") || strings.EqualFold(base, "README.md") {
+		return false
+	}
+	if hugo {
+		return strings.HasPrefix(name, "content/") || strings.Contains(name, "/content/")
+	}
+
+	return true
+}
+
+func isRemote(link string) bool {
+	lower := strings.ToLower(link)
+	for _, prefix := range []string{"http://", "https://", "//", "data:", "mailto:"} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
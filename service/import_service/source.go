@@ -0,0 +1,127 @@
+package import_service
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+var ErrFileTooLarge = errors.New("file in the archive is too large")
+
+// Source is a tree of files to import from, either a directory or a zip archive.
+// Names are slash separated and relative to the root of the tree
+type Source interface {
+	Names() []string
+	ReadFile(name string) ([]byte, error)
+}
+
+type dirSource struct {
+	root  string
+	names []string
+}
+
+// OpenDir walks a directory into a Source
+func OpenDir(root string) (Source, error) {
+	s := &dirSource{root: root}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p != root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		s.names = append(s.names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(s.names)
+	return s, nil
+}
+
+func (s *dirSource) Names() []string {
+	return s.names
+}
+
+func (s *dirSource) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(s.root, filepath.FromSlash(name)))
+}
+
+type zipSource struct {
+	files map[string]*zip.File
+	names []string
+}
+
+// OpenZip reads the directory of a zip archive into a Source
+func OpenZip(r io.ReaderAt, size int64) (Source, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &zipSource{files: make(map[string]*zip.File)}
+	for _, f := range zr.File {
+		name := path.Clean(strings.TrimPrefix(f.Name, "/"))
+		if f.FileInfo().IsDir() || strings.HasPrefix(name, "../") || strings.HasPrefix(name, "__MACOSX/") {
+			continue
+		}
+
+		s.files[name] = f
+		s.names = append(s.names, name)
+	}
+
+	sort.Strings(s.names)
+	return s, nil
+}
+
+func (s *zipSource) Names() []string {
+	return s.names
+}
+
+func (s *zipSource) ReadFile(name string) ([]byte, error) {
+	f, ok := s.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	// entries are held in memory, so one claiming or turning out to unpack to
+	// more than an archive may weigh is refused rather than read
+	max := setting.ImportSetting.MaxSize
+	if f.UncompressedSize64 > uint64(max) {
+		return nil, ErrFileTooLarge
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(rc, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, ErrFileTooLarge
+	}
+
+	return data, nil
+}
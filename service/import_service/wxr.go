@@ -0,0 +1,377 @@
+package import_service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/html2md"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+)
+
+const (
+	SOURCE_WORDPRESS = "wordpress"
+
+	KIND_ARTICLE = "article"
+	KIND_COMMENT = "comment"
+
+	wxrTimeFormat = "2006-01-02 15:04:05"
+	wxrZeroTime   = "0000-00-00 00:00:00"
+)
+
+var (
+	// uploadUrl matches links into the WordPress media library, thumbnails included
+	uploadUrl = regexp.MustCompile(`(?:https?:)?//[^\s"'()<>\]]+/wp-content/uploads/([^\s"'()<>\]?#]+)`)
+
+	codeShortcode    = regexp.MustCompile(`(?s)\[(code|sourcecode)([^\]]*)\](.*?)\[/(?:code|sourcecode)\]`)
+	embedShortcode   = regexp.MustCompile(`(?s)\[embed[^\]]*\](.*?)\[/embed\]`)
+	wrapperShortcode = regexp.MustCompile(`\[/?(caption|wp_caption)[^\]]*\]`)
+	mediaShortcode   = regexp.MustCompile(`\[(gallery|audio|video|playlist)[^\]]*\](?:[^\[]*\[/(?:audio|video)\])?`)
+	shortcodeLang    = regexp.MustCompile(`\b(?:lang|language)=["']?([\w+#-]+)`)
+)
+
+type wxrDocument struct {
+	Channel struct {
+		Authors []struct {
+			Login       string `xml:"author_login"`
+			DisplayName string `xml:"author_display_name"`
+		} `xml:"author"`
+		Items []wxrItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrItem struct {
+	Title    string       `xml:"title"`
+	GUID     string       `xml:"guid"`
+	Creator  string       `xml:"creator"`
+	Encoded  []wxrEncoded `xml:"encoded"`
+	PostID   int          `xml:"post_id"`
+	PostDate string       `xml:"post_date"`
+	DateGMT  string       `xml:"post_date_gmt"`
+	Status   string       `xml:"status"`
+	PostType string       `xml:"post_type"`
+	FileUrl  string       `xml:"attachment_url"`
+	Terms    []struct {
+		Domain string `xml:"domain,attr"`
+		Name   string `xml:",chardata"`
+	} `xml:"category"`
+	Meta []struct {
+		Key   string `xml:"meta_key"`
+		Value string `xml:"meta_value"`
+	} `xml:"postmeta"`
+	Comments []wxrComment `xml:"comment"`
+}
+
+// wxrEncoded is either content:encoded or excerpt:encoded, told apart by namespace
+type wxrEncoded struct {
+	XMLName xml.Name `xml:"encoded"`
+	Value   string   `xml:",chardata"`
+}
+
+type wxrComment struct {
+	ID       int    `xml:"comment_id"`
+	Author   string `xml:"comment_author"`
+	Email    string `xml:"comment_author_email"`
+	Url      string `xml:"comment_author_url"`
+	Date     string `xml:"comment_date"`
+	DateGMT  string `xml:"comment_date_gmt"`
+	Content  string `xml:"comment_content"`
+	Approved string `xml:"comment_approved"`
+	Type     string `xml:"comment_type"`
+	Parent   int    `xml:"comment_parent"`
+}
+
+// WordPress imports the posts of a WXR export together with their categories,
+// tags, authors and comments. Media is not downloaded: links into wp-content/uploads
+// are served from a local copy of that directory when UploadsDir is set.
+// Every imported post and comment is remembered by its GUID so re-runs skip them
+type WordPress struct {
+	UploadsDir string
+	CreatedBy  string
+	DryRun     bool
+
+	authors     map[string]string
+	attachments map[int]string
+	tags        *tagCache
+	images      map[string]string
+}
+
+// Run imports the posts of the WXR document read from r
+func (w *WordPress) Run(r io.Reader) (*Report, error) {
+	var doc wxrDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	w.tags = newTagCache(w.CreatedBy, w.DryRun)
+	w.images = make(map[string]string)
+	w.authors = make(map[string]string)
+	for _, author := range doc.Channel.Authors {
+		w.authors[author.Login] = author.DisplayName
+	}
+	w.attachments = make(map[int]string)
+	for _, item := range doc.Channel.Items {
+		if item.PostType == "attachment" {
+			w.attachments[item.PostID] = item.FileUrl
+		}
+	}
+
+	report := &Report{DryRun: w.DryRun}
+	for i := range doc.Channel.Items {
+		item := &doc.Channel.Items[i]
+		if item.PostType != "post" || item.Status == "trash" || item.Status == "auto-draft" {
+			continue
+		}
+
+		result := &Result{File: item.GUID, Title: item.Title}
+		if err := w.importItem(item, result); err != nil {
+			result.Error = err.Error()
+		}
+		report.add(result)
+	}
+
+	return report, nil
+}
+
+func (w *WordPress) importItem(item *wxrItem, result *Result) error {
+	if item.GUID == "" {
+		return fmt.Errorf("post %d has no guid", item.PostID)
+	}
+
+	articleID, err := models.GetImportTarget(SOURCE_WORDPRESS, item.GUID)
+	if err != nil {
+		return err
+	}
+	if articleID > 0 {
+		// the post is already here, but comments may have been added since
+		result.Status = STATUS_SKIPPED
+		result.ArticleID = articleID
+		return w.importComments(item, articleID, result)
+	}
+
+	for _, term := range item.Terms {
+		if term.Domain == "category" || term.Domain == "post_tag" {
+			result.Tags = append(result.Tags, strings.TrimSpace(term.Name))
+		}
+	}
+	if len(result.Tags) == 0 && setting.ImportSetting.DefaultTag != "" {
+		result.Tags = []string{setting.ImportSetting.DefaultTag}
+	}
+	if len(result.Tags) == 0 {
+		return fmt.Errorf("post has no categories or tags")
+	}
+
+	tagID, err := w.tags.ensureFirst(result)
+	if err != nil {
+		return err
+	}
+
+	content, err := html2md.Convert(convertShortcodes(item.content()))
+	if err != nil {
+		return err
+	}
+	content = w.rewriteUploads(content, result)
+
+	desc, err := html2md.Convert(item.excerpt())
+	if err != nil {
+		return err
+	}
+
+	var cover string
+	if id, err := strconv.Atoi(item.meta("_thumbnail_id")); err == nil && w.attachments[id] != "" {
+		cover = w.rewriteUploads(w.attachments[id], result)
+	}
+
+	createdBy := w.authors[item.Creator]
+	if createdBy == "" {
+		createdBy = item.Creator
+	}
+	if createdBy == "" {
+		createdBy = w.CreatedBy
+	}
+
+	state := 0
+	if item.Status == "publish" {
+		state = 1
+	}
+
+	articleService := article_service.Article{
+		TagID:         tagID,
+		Title:         item.Title,
+		Desc:          strings.TrimSpace(desc),
+		Content:       strings.TrimSpace(content),
+		CoverImageUrl: cover,
+		State:         state,
+		CreatedBy:     createdBy,
+		CreatedOn:     parseWXRTime(item.PostDate, item.DateGMT),
+	}
+
+	if w.DryRun {
+		return w.importComments(item, 0, result)
+	}
+	if err := articleService.AddImported(SOURCE_WORDPRESS, item.GUID, KIND_ARTICLE); err != nil {
+		return err
+	}
+
+	result.ArticleID = articleService.ID
+	return w.importComments(item, articleService.ID, result)
+}
+
+// importComments adds the comments of a post not imported before, keeping replies
+// attached to their parents. Spam, trashed comments and pingbacks are left out
+func (w *WordPress) importComments(item *wxrItem, articleID int, result *Result) error {
+	ids := make(map[int]int)
+	for _, comment := range item.Comments {
+		if comment.Approved == "spam" || comment.Approved == "trash" ||
+			comment.Type == "pingback" || comment.Type == "trackback" {
+			continue
+		}
+
+		guid := item.GUID + "#comment-" + strconv.Itoa(comment.ID)
+		id, err := models.GetImportTarget(SOURCE_WORDPRESS, guid)
+		if err != nil {
+			return err
+		}
+		if id > 0 {
+			ids[comment.ID] = id
+			continue
+		}
+
+		result.Comments++
+		if w.DryRun {
+			continue
+		}
+
+		state := 0
+		if comment.Approved == "1" {
+			state = 1
+		}
+		id, err = models.AddImportedComment(map[string]interface{}{
+			"article_id": articleID,
+			"parent_id":  ids[comment.Parent],
+			"author":     comment.Author,
+			"email":      comment.Email,
+			"url":        comment.Url,
+			"content":    comment.Content,
+			"state":      state,
+			"created_on": parseWXRTime(comment.Date, comment.DateGMT),
+		}, SOURCE_WORDPRESS, guid, KIND_COMMENT)
+		if err != nil {
+			return err
+		}
+
+		ids[comment.ID] = id
+	}
+
+	return nil
+}
+
+// rewriteUploads replaces links into wp-content/uploads by uploaded copies of the
+// files found in UploadsDir, leaving links to missing or non-image files alone
+func (w *WordPress) rewriteUploads(content string, result *Result) string {
+	return uploadUrl.ReplaceAllStringFunc(content, func(link string) string {
+		rel := path.Clean(uploadUrl.FindStringSubmatch(link)[1])
+		if url, ok := w.images[rel]; ok {
+			result.Images = append(result.Images, rel)
+			return url
+		}
+		if w.UploadsDir == "" || strings.HasPrefix(rel, "../") {
+			return link
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(w.UploadsDir, filepath.FromSlash(rel)))
+		if os.IsNotExist(err) {
+			result.Warnings = append(result.Warnings, "upload not found: "+rel)
+			return link
+		}
+		var url string
+		if err == nil {
+			url, err = saveImage(rel, data, w.CreatedBy, w.DryRun)
+		}
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("upload %s: %v", rel, err))
+			return link
+		}
+
+		w.images[rel] = url
+		result.Images = append(result.Images, rel)
+		return url
+	})
+}
+
+func (item *wxrItem) content() string {
+	for _, e := range item.Encoded {
+		if !strings.Contains(e.XMLName.Space, "excerpt") {
+			return e.Value
+		}
+	}
+
+	return ""
+}
+
+func (item *wxrItem) excerpt() string {
+	for _, e := range item.Encoded {
+		if strings.Contains(e.XMLName.Space, "excerpt") {
+			return e.Value
+		}
+	}
+
+	return ""
+}
+
+func (item *wxrItem) meta(key string) string {
+	for _, m := range item.Meta {
+		if m.Key == key {
+			return m.Value
+		}
+	}
+
+	return ""
+}
+
+// convertShortcodes rewrites the WordPress shortcodes that carry content into
+// HTML the Markdown conversion understands, and drops the purely dynamic ones
+func convertShortcodes(content string) string {
+	content = codeShortcode.ReplaceAllStringFunc(content, func(match string) string {
+		parts := codeShortcode.FindStringSubmatch(match)
+		class := ""
+		if lang := shortcodeLang.FindStringSubmatch(parts[2]); lang != nil {
+			class = ` class="language-` + lang[1] + `"`
+		}
+		// the body of a code shortcode is raw source, not HTML
+		return "<pre" + class + ">" + escapeHTML(strings.Trim(parts[3], "\r\n")) + "</pre>"
+	})
+	content = embedShortcode.ReplaceAllString(content, "\n\n$1\n\n")
+	content = mediaShortcode.ReplaceAllString(content, "")
+	content = wrapperShortcode.ReplaceAllString(content, "")
+
+	return content
+}
+
+func escapeHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// parseWXRTime prefers the GMT date, which WordPress leaves zero for drafts
+func parseWXRTime(local, gmt string) int {
+	if gmt != "" && gmt != wxrZeroTime {
+		if t, err := time.Parse(wxrTimeFormat, gmt); err == nil {
+			return int(t.Unix())
+		}
+	}
+	if t, err := time.ParseInLocation(wxrTimeFormat, local, time.Local); err == nil {
+		return int(t.Unix())
+	}
+
+	return 0
+}
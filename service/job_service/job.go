@@ -0,0 +1,240 @@
+package job_service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/export"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+const (
+	STATUS_QUEUED  = "queued"
+	STATUS_RUNNING = "running"
+	STATUS_DONE    = "done"
+	STATUS_FAILED  = "failed"
+
+	KIND_EXPORT_TAGS     = "export_tags"
+	KIND_EXPORT_ARTICLES = "export_articles"
+	KIND_BACKUP          = "backup"
+)
+
+var ErrQueueFull = errors.New("export queue is full")
+
+// RunFunc builds the export file of the job with the id, reporting its progress,
+// and returns the file name
+type RunFunc func(id string, progress export.ProgressFunc) (string, error)
+
+// Job is the state of an export as seen by the client polling it
+type Job struct {
+	ID         string `json:"id"`
+	Kind       string `json:"kind"`
+	Status     string `json:"status"`
+	Progress   int    `json:"progress"`
+	Done       int    `json:"done"`
+	Total      int    `json:"total"`
+	File       string `json:"file,omitempty"`
+	Error      string `json:"error,omitempty"`
+	CreatedOn  int64  `json:"created_on"`
+	FinishedOn int64  `json:"finished_on,omitempty"`
+}
+
+type task struct {
+	job *Job
+	run RunFunc
+}
+
+var (
+	queue chan *task
+	once  sync.Once
+)
+
+// Setup starts the workers that run queued exports, after failing the jobs a
+// previous run of the server left unfinished, as the queue is lost on exit
+func Setup() {
+	once.Do(func() {
+		if err := failUnfinished(); err != nil {
+			logging.Warn("job_service.Setup err:", err)
+		}
+
+		queue = make(chan *task, setting.ExportSetting.QueueSize)
+		for i := 0; i < setting.ExportSetting.Workers; i++ {
+			go work()
+		}
+	})
+}
+
+// Enqueue queues an export and returns its job, refusing it when the queue is full
+func Enqueue(kind string, run RunFunc) (*Job, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{
+		ID:        id,
+		Kind:      kind,
+		Status:    STATUS_QUEUED,
+		CreatedOn: time.Now().Unix(),
+	}
+	if err := save(job); err != nil {
+		return nil, err
+	}
+
+	// the worker updates its own copy, so that the caller reads the job safely
+	queued := *job
+	select {
+	case queue <- &task{job: job, run: run}:
+		return &queued, nil
+	default:
+		gredis.Delete(getKey(id))
+		return nil, ErrQueueFull
+	}
+}
+
+// Get returns a job, or nil if there is no such job or it has expired
+func Get(id string) (*Job, error) {
+	key := getKey(id)
+	if !gredis.Exists(key) {
+		return nil, nil
+	}
+
+	data, err := gredis.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// failUnfinished marks the jobs still queued or running as failed
+func failUnfinished() error {
+	cache := cache_service.ExportJob{ID: "*"}
+	keys, err := gredis.Keys(cache.GetExportJobKey())
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		job, err := Get(strings.TrimPrefix(key, getKey("")))
+		if err != nil || job == nil {
+			continue
+		}
+		if job.Status != STATUS_QUEUED && job.Status != STATUS_RUNNING {
+			continue
+		}
+
+		job.Status = STATUS_FAILED
+		job.Error = "interrupted by a restart of the server"
+		job.FinishedOn = time.Now().Unix()
+		saveOrLog(job)
+	}
+
+	return nil
+}
+
+// Clean deletes the export files older than the retention period
+func Clean() error {
+	files, err := storage.Exports.List("")
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(-setting.ExportSetting.Retention)
+	for _, f := range files {
+		if f.ModTime.After(deadline) {
+			continue
+		}
+		if err := storage.Exports.Delete(f.Name); err != nil {
+			logging.Warn("job_service.Clean remove", f.Name, "err:", err)
+		}
+	}
+
+	return nil
+}
+
+func work() {
+	for t := range queue {
+		runTask(t)
+	}
+}
+
+func runTask(t *task) {
+	job := t.job
+	job.Status = STATUS_RUNNING
+	saveOrLog(job)
+
+	defer func() {
+		if r := recover(); r != nil {
+			job.Status = STATUS_FAILED
+			job.Error = fmt.Sprint(r)
+			job.FinishedOn = time.Now().Unix()
+			saveOrLog(job)
+		}
+	}()
+
+	file, err := t.run(job.ID, func(done, total int) {
+		progress := 100
+		if total > 0 {
+			progress = done * 100 / total
+		}
+		// only write the job back when the percentage moves
+		if progress == job.Progress && done != total {
+			return
+		}
+
+		job.Done, job.Total, job.Progress = done, total, progress
+		saveOrLog(job)
+	})
+
+	job.FinishedOn = time.Now().Unix()
+	if err != nil {
+		logging.Warn("job_service run", job.Kind, job.ID, "err:", err)
+		job.Status = STATUS_FAILED
+		job.Error = err.Error()
+	} else {
+		job.Status = STATUS_DONE
+		job.Progress = 100
+		job.File = file
+	}
+	saveOrLog(job)
+}
+
+func save(job *Job) error {
+	return gredis.Set(getKey(job.ID), job, int(setting.ExportSetting.Retention/time.Second))
+}
+
+func saveOrLog(job *Job) {
+	if err := save(job); err != nil {
+		logging.Warn("job_service save", job.ID, "err:", err)
+	}
+}
+
+func getKey(id string) string {
+	cache := cache_service.ExportJob{ID: id}
+	return cache.GetExportJobKey()
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
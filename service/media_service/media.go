@@ -0,0 +1,201 @@
+package media_service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"net/http"
+	"path"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+)
+
+var ErrMediaInUse = errors.New("media is used by an article")
+
+// Media is an upload to store, its Data already checked by upload.CleanImage,
+// or the media library entry to look up, edit or delete
+type Media struct {
+	ID           int
+	Data         []byte
+	OriginalName string
+	AltText      string
+	Caption      string
+	ContentType  string
+	Owner        string
+	CreatedBy    string
+	Keyword      string
+
+	PageNum  int
+	PageSize int
+}
+
+// Save stores the data under its content hash. When the same bytes were stored
+// before, the existing media is returned and reported as such
+func (m *Media) Save() (*models.Media, bool, error) {
+	hash := upload.GetImageHash(m.Data)
+	existing, err := models.GetMediaByHash(hash)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return m.reuse(existing)
+	}
+
+	media := &models.Media{
+		Hash:         hash,
+		Name:         upload.GetHashName(hash, path.Ext(m.OriginalName)),
+		OriginalName: m.OriginalName,
+		ContentType:  http.DetectContentType(m.Data),
+		Size:         len(m.Data),
+		AltText:      m.AltText,
+		Caption:      m.Caption,
+		Owner:        m.Owner,
+		CreatedBy:    m.CreatedBy,
+	}
+	if config, _, err := image.DecodeConfig(bytes.NewReader(m.Data)); err == nil {
+		media.Width = config.Width
+		media.Height = config.Height
+	}
+
+	if err := m.write(media.Name); err != nil {
+		return nil, false, err
+	}
+	if err := models.AddMedia(media); err != nil {
+		// the same bytes may have been stored by a concurrent upload
+		if existing, _ := models.GetMediaByHash(hash); existing != nil {
+			return existing, true, nil
+		}
+		return nil, false, err
+	}
+
+	return media, false, nil
+}
+
+// reuse returns a media stored before, undeleting it and putting its file back if needed
+func (m *Media) reuse(media *models.Media) (*models.Media, bool, error) {
+	if media.DeletedOn > 0 {
+		if err := models.RestoreMedia(media.ID); err != nil {
+			return nil, false, err
+		}
+		media.DeletedOn = 0
+	}
+
+	if err := m.write(media.Name); err != nil {
+		return nil, false, err
+	}
+
+	return media, true, nil
+}
+
+// write stores the data unless it is there already. As the name is the content
+// hash, a file under it always holds the same bytes
+func (m *Media) write(name string) error {
+	if storage.Exists(storage.Images, name) {
+		return nil
+	}
+
+	return storage.Images.Put(name, bytes.NewReader(m.Data))
+}
+
+func (m *Media) Get() (*models.Media, error) {
+	media, err := models.GetMedia(m.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return media, fillReferences(media)
+}
+
+func (m *Media) GetAll() ([]*models.Media, error) {
+	media, err := models.GetMediaList(m.PageNum, m.PageSize, m.getMaps(), m.Keyword)
+	if err != nil {
+		return nil, err
+	}
+
+	return media, fillReferences(media...)
+}
+
+func (m *Media) Count() (int, error) {
+	return models.GetMediaTotal(m.getMaps(), m.Keyword)
+}
+
+func (m *Media) ExistByID() (bool, error) {
+	return models.ExistMediaByID(m.ID)
+}
+
+// Edit changes the description of a media
+func (m *Media) Edit() error {
+	return models.EditMedia(m.ID, map[string]interface{}{
+		"alt_text": m.AltText,
+		"caption":  m.Caption,
+	})
+}
+
+// Rename changes the file name the media is known by
+func (m *Media) Rename() error {
+	return models.EditMedia(m.ID, map[string]interface{}{
+		"original_name": m.OriginalName,
+	})
+}
+
+// GetArticles returns the articles using the media
+func (m *Media) GetArticles() ([]*models.Article, error) {
+	return models.GetMediaArticles(m.ID)
+}
+
+// Delete deletes the media and its files, failing with ErrMediaInUse when an
+// article uses it
+func (m *Media) Delete() error {
+	media, err := models.GetMedia(m.ID)
+	if err != nil {
+		return err
+	}
+	deleted, err := models.DeleteUnusedMedia(m.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrMediaInUse
+	}
+
+	if err := storage.Images.Delete(media.Name); err != nil {
+		logging.Warn("media_service.Delete", media.Name, "err:", err)
+	}
+	upload.RemoveVariants(media.Name)
+	return nil
+}
+
+func (m *Media) getMaps() map[string]interface{} {
+	maps := make(map[string]interface{})
+	maps["deleted_on"] = 0
+	if m.CreatedBy != "" {
+		maps["created_by"] = m.CreatedBy
+	}
+	if m.ContentType != "" {
+		maps["content_type"] = m.ContentType
+	}
+
+	return maps
+}
+
+// fillReferences attaches how many articles use each media
+func fillReferences(media ...*models.Media) error {
+	ids := make([]int, 0, len(media))
+	for _, item := range media {
+		ids = append(ids, item.ID)
+	}
+
+	counts, err := models.CountMediaReferences(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range media {
+		item.References = counts[item.ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package media_service
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+)
+
+// rebuildBatchSize is how many articles RebuildReferences reads at a time
+const rebuildBatchSize = 200
+
+// imageNameRegexp matches the name of an image following the image save path
+var imageNameRegexp = regexp.MustCompile(`^[0-9A-Za-z_./-]+`)
+
+// FindImageNames finds the names of uploaded images linked from text, by the
+// image save path in front of them. That path is part of image URLs whichever
+// storage serves them, so links are found on local disk and S3 alike
+func FindImageNames(text string) []string {
+	prefix := upload.GetImagePath()
+	if prefix == "" {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for {
+		i := strings.Index(text, prefix)
+		if i < 0 {
+			return names
+		}
+		text = text[i+len(prefix):]
+
+		// a link ending a sentence is followed by its full stop
+		name := strings.TrimRight(imageNameRegexp.FindString(text), ".")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+}
+
+// FindReferences finds the media an article uses as its cover image and through
+// the image links of its content
+func FindReferences(coverImageUrl, content string) ([]models.MediaReference, error) {
+	covers := FindImageNames(coverImageUrl)
+	contents := FindImageNames(content)
+
+	media, err := models.GetMediaByNames(append(append([]string{}, covers...), contents...))
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int, len(media))
+	for _, item := range media {
+		ids[item.Name] = item.ID
+	}
+
+	var references []models.MediaReference
+	for _, name := range covers {
+		if id, ok := ids[name]; ok {
+			references = append(references, models.MediaReference{MediaID: id, Source: models.MEDIA_SOURCE_COVER})
+		}
+	}
+	for _, name := range contents {
+		if id, ok := ids[name]; ok {
+			references = append(references, models.MediaReference{MediaID: id, Source: models.MEDIA_SOURCE_CONTENT})
+		}
+	}
+
+	return references, nil
+}
+
+// TrackArticle records which media an article uses, replacing what was recorded before
+func TrackArticle(articleID int, coverImageUrl, content string) error {
+	references, err := FindReferences(coverImageUrl, content)
+	if err != nil {
+		return err
+	}
+
+	return models.SetArticleMediaReferences(articleID, references)
+}
+
+// RebuildReferences tracks every article again, as for articles written before
+// references were recorded. It returns how many articles were scanned
+func RebuildReferences() (int, error) {
+	count, lastID := 0, 0
+	for {
+		articles, err := models.GetArticlesAfter(lastID, rebuildBatchSize, map[string]interface{}{"deleted_on": 0})
+		if err != nil {
+			return count, err
+		}
+		if len(articles) == 0 {
+			return count, nil
+		}
+
+		for _, article := range articles {
+			if err := TrackArticle(article.ID, article.CoverImageUrl, article.Content); err != nil {
+				return count, err
+			}
+			count++
+		}
+		lastID = articles[len(articles)-1].ID
+	}
+}
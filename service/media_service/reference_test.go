@@ -0,0 +1,48 @@
+package media_service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+func TestFindImageNames(t *testing.T) {
+	setting.AppSetting.ImageSavePath = "upload/images/"
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "no links",
+			text: "Nothing to see here.",
+		},
+		{
+			name: "markdown and html",
+			text: "![a](http://127.0.0.1:8000/upload/images/ab/abc.png) <img src=\"/upload/images/cd/cde.jpg?w=640\">",
+			want: []string{"ab/abc.png", "cd/cde.jpg"},
+		},
+		{
+			name: "s3 url and full stop",
+			text: "See https://blog.s3.amazonaws.com/upload/images/ab/abc.png.",
+			want: []string{"ab/abc.png"},
+		},
+		{
+			name: "duplicates",
+			text: "upload/images/a.png upload/images/a.png upload/images/b.gif",
+			want: []string{"a.png", "b.gif"},
+		},
+		{
+			name: "save path alone",
+			text: "upload/images/ and upload/images/\"",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := FindImageNames(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: FindImageNames() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
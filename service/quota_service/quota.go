@@ -0,0 +1,209 @@
+package quota_service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+var ErrQuotaExceeded = errors.New("upload quota exceeded")
+
+// User is the account uploading, as its token names it. Owner is what uploads
+// are recorded under, Name who they are credited to
+type User struct {
+	Owner string
+	Role  string
+	Name  string
+}
+
+// Reservation is room held in the quota for an upload being saved
+type Reservation struct {
+	key  string
+	size int64
+}
+
+// reserveExpire is how long, in seconds, reserved room outlives the last
+// upload of an account, in case a crash kept it from being released
+const reserveExpire = 3600
+
+// Usage is the storage an account fills with its uploads. Images shared with
+// other accounts count for the first to upload them
+type Usage struct {
+	Role        string `json:"role"`
+	Media       int64  `json:"media"`
+	Attachments int64  `json:"attachments"`
+	Used        int64  `json:"used"`
+	// Limit is 0 when the role has no limit, and so is Remaining
+	Limit     int64 `json:"limit"`
+	Remaining int64 `json:"remaining"`
+}
+
+// CanChange reports whether the account may change or delete an upload of the
+// owner: its own uploads, or any as an administrator
+func (u *User) CanChange(owner string) bool {
+	return u.Role == util.ADMIN_ROLE || (u.Owner != "" && u.Owner == owner)
+}
+
+// GetRole get the role the quota is taken from, the default one for accounts
+// without a configured role
+func (u *User) GetRole() string {
+	if _, ok := getLimits()[u.Role]; ok {
+		return u.Role
+	}
+
+	return setting.QuotaSetting.DefaultRole
+}
+
+// GetUsage adds up what the account uploaded
+func (u *User) GetUsage() (*Usage, error) {
+	media, err := models.SumMediaSize(u.Owner)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := models.SumAttachmentSize(u.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	role := u.GetRole()
+	usage := &Usage{
+		Role:        role,
+		Media:       media,
+		Attachments: attachments,
+		Used:        media + attachments,
+		Limit:       getLimits()[role],
+	}
+	if usage.Limit > 0 && usage.Limit > usage.Used {
+		usage.Remaining = usage.Limit - usage.Used
+	}
+
+	return usage, nil
+}
+
+// Check returns ErrQuotaExceeded when size more bytes do not fit in the quota
+func (u *User) Check(size int64) error {
+	usage, err := u.GetUsage()
+	if err != nil {
+		return err
+	}
+	if exceeds(usage, 0, size) {
+		return ErrQuotaExceeded
+	}
+
+	return nil
+}
+
+// Reserve holds size bytes of the quota while an upload is saved, so that
+// uploads of one account saved at the same time cannot together go past it.
+// The room is counted in Redis, on top of the usage, until Release, by which
+// time the saved upload is part of the usage. Without Redis it only checks
+func (u *User) Reserve(size int64) (*Reservation, error) {
+	if getLimits()[u.GetRole()] == 0 {
+		return &Reservation{}, nil
+	}
+
+	cache := cache_service.UploadReserved{Owner: u.Owner}
+	r := &Reservation{key: cache.GetUploadReservedKey(), size: size}
+	reserved, err := gredis.IncrBy(r.key, int(size), reserveExpire)
+	if err != nil {
+		logging.Warn("quota_service.Reserve", u.Owner, "err:", err)
+		return &Reservation{}, u.Check(size)
+	}
+
+	usage, err := u.GetUsage()
+	if err == nil && exceeds(usage, int64(reserved)-size, size) {
+		err = ErrQuotaExceeded
+	}
+	if err != nil {
+		r.Release()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Release gives the reserved room back, once the upload is saved or failed
+func (r *Reservation) Release() {
+	if r.key == "" {
+		return
+	}
+
+	if _, err := gredis.IncrBy(r.key, -int(r.size), reserveExpire); err != nil {
+		logging.Warn("quota_service.Release", r.key, "err:", err)
+	}
+}
+
+// exceeds reports whether size more bytes go past the limit of the usage, with
+// reserved bytes held by other uploads in progress
+func exceeds(usage *Usage, reserved, size int64) bool {
+	return usage.Limit > 0 && usage.Used+reserved+size > usage.Limit
+}
+
+// Allow counts an upload against the rate limit and reports whether it may go
+// ahead, or else how long until the next window. Uploads are let through when
+// Redis cannot count them
+func (u *User) Allow() (bool, time.Duration) {
+	limit := setting.QuotaSetting.RateLimit
+	window := setting.QuotaSetting.RateWindow
+	if limit <= 0 || window <= 0 {
+		return true, 0
+	}
+
+	now := time.Now()
+	start := now.Truncate(window)
+	cache := cache_service.UploadRate{Owner: u.Owner, Window: start.Unix()}
+	count, err := gredis.IncrBy(cache.GetUploadRateKey(), 1, int(window/time.Second)+1)
+	if err != nil {
+		logging.Warn("quota_service.Allow", u.Owner, "err:", err)
+		return true, 0
+	}
+
+	return checkRate(count, limit, now, window)
+}
+
+// checkRate decides on the count-th upload of the window now falls in
+func checkRate(count, limit int, now time.Time, window time.Duration) (bool, time.Duration) {
+	if count > limit {
+		return false, now.Truncate(window).Add(window).Sub(now)
+	}
+
+	return true, 0
+}
+
+// getLimits get the quota of each role in bytes
+func getLimits() map[string]int64 {
+	limits := make(map[string]int64)
+	for _, r := range setting.QuotaSetting.Roles {
+		role, limit, ok := parseLimit(r)
+		if !ok {
+			logging.Warn("invalid upload quota: ", r)
+			continue
+		}
+
+		limits[role] = limit
+	}
+
+	return limits
+}
+
+// parseLimit parses the quota of a role, as role:MB, into bytes
+func parseLimit(r string) (string, int64, bool) {
+	i := strings.LastIndex(r, ":")
+	if i < 0 {
+		return "", 0, false
+	}
+	mb, err := strconv.ParseInt(strings.TrimSpace(r[i+1:]), 10, 64)
+	if err != nil || mb < 0 {
+		return "", 0, false
+	}
+
+	return strings.TrimSpace(r[:i]), mb * 1024 * 1024, true
+}
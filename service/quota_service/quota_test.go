@@ -0,0 +1,92 @@
+package quota_service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCanChange(t *testing.T) {
+	tests := []struct {
+		user  User
+		owner string
+		want  bool
+	}{
+		{User{Owner: "a", Role: "author"}, "a", true},
+		{User{Owner: "a", Role: "author"}, "b", false},
+		{User{Owner: "a", Role: "admin"}, "b", true},
+		{User{Owner: "", Role: "author"}, "", false},
+		{User{Owner: "a", Role: ""}, "", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.user.CanChange(tt.owner); got != tt.want {
+			t.Errorf("%+v CanChange(%q) = %v, want %v", tt.user, tt.owner, got, tt.want)
+		}
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value string
+		role  string
+		limit int64
+		ok    bool
+	}{
+		{"admin:0", "admin", 0, true},
+		{"author:500", "author", 500 << 20, true},
+		{" contributor : 50 ", "contributor", 50 << 20, true},
+		{"author", "", 0, false},
+		{"author:-1", "", 0, false},
+		{"author:many", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		role, limit, ok := parseLimit(tt.value)
+		if role != tt.role || limit != tt.limit || ok != tt.ok {
+			t.Errorf("parseLimit(%q) = %q, %d, %v, want %q, %d, %v", tt.value, role, limit, ok, tt.role, tt.limit, tt.ok)
+		}
+	}
+}
+
+func TestExceeds(t *testing.T) {
+	tests := []struct {
+		name     string
+		usage    Usage
+		reserved int64
+		size     int64
+		want     bool
+	}{
+		{"no limit", Usage{Used: 1 << 40}, 1 << 40, 1 << 40, false},
+		{"fits", Usage{Used: 60, Limit: 100}, 0, 40, false},
+		{"over", Usage{Used: 60, Limit: 100}, 0, 41, true},
+		{"fits beside reserved", Usage{Used: 60, Limit: 100}, 20, 20, false},
+		{"over with reserved", Usage{Used: 60, Limit: 100}, 20, 21, true},
+	}
+
+	for _, tt := range tests {
+		if got := exceeds(&tt.usage, tt.reserved, tt.size); got != tt.want {
+			t.Errorf("%s: exceeds() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRate(t *testing.T) {
+	now := time.Date(2019, 5, 1, 10, 0, 45, 0, time.UTC)
+
+	tests := []struct {
+		count int
+		ok    bool
+		wait  time.Duration
+	}{
+		{1, true, 0},
+		{30, true, 0},
+		{31, false, 15 * time.Second},
+	}
+
+	for _, tt := range tests {
+		ok, wait := checkRate(tt.count, 30, now, time.Minute)
+		if ok != tt.ok || wait != tt.wait {
+			t.Errorf("checkRate(%d) = %v, %v, want %v, %v", tt.count, ok, wait, tt.ok, tt.wait)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package reaction_service
+
+import (
+	"strconv"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+const cacheExpire = 3600
+
+type Reaction struct {
+	ArticleID int
+	Kind      string
+	Actor     string
+}
+
+// CheckKind reports whether the kind is one of the configured reactions
+func CheckKind(kind string) bool {
+	for _, k := range setting.ReactionSetting.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Add reacts to the article once per actor and kind, returning whether a new reaction was recorded
+func (r *Reaction) Add() (bool, error) {
+	added, err := models.AddArticleReaction(r.ArticleID, r.Kind, r.Actor)
+	if err != nil || !added {
+		return false, err
+	}
+
+	r.incr(1)
+	return true, nil
+}
+
+// Remove withdraws the actor's reaction, returning whether one existed
+func (r *Reaction) Remove() (bool, error) {
+	removed, err := models.DeleteArticleReaction(r.ArticleID, r.Kind, r.Actor)
+	if err != nil || !removed {
+		return false, err
+	}
+
+	r.incr(-1)
+	return true, nil
+}
+
+// RemoveAll withdraws every reaction to the article, as when it is deleted
+func (r *Reaction) RemoveAll() error {
+	if err := models.DeleteArticleReactions(r.ArticleID); err != nil {
+		return err
+	}
+
+	cache := cache_service.Reaction{ArticleID: r.ArticleID}
+	if _, err := gredis.Delete(cache.GetReactionKey()); err != nil {
+		logging.Info(err)
+	}
+	return nil
+}
+
+// Count returns the reaction counters of the article
+func (r *Reaction) Count() (map[string]int, error) {
+	counts, err := GetCounts([]int{r.ArticleID})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts[r.ArticleID], nil
+}
+
+// incr bumps the Redis counter when it is warm; a cold counter is rebuilt from MySQL on the next read
+func (r *Reaction) incr(delta int) {
+	cache := cache_service.Reaction{ArticleID: r.ArticleID}
+	key := cache.GetReactionKey()
+	if gredis.Exists(key) {
+		if _, err := gredis.HIncrBy(key, r.Kind, delta); err != nil {
+			logging.Info(err)
+		}
+	}
+
+	if err := gredis.SAdd(cache.GetDirtyKey(), r.ArticleID); err != nil {
+		logging.Info(err)
+	}
+}
+
+// GetCounts returns the reaction counters of several articles, reading Redis first and MySQL for misses
+func GetCounts(articleIDs []int) (map[int]map[string]int, error) {
+	counts := make(map[int]map[string]int, len(articleIDs))
+
+	var misses []int
+	for _, id := range articleIDs {
+		cache := cache_service.Reaction{ArticleID: id}
+		key := cache.GetReactionKey()
+		if gredis.Exists(key) {
+			data, err := gredis.HGetAllInt(key)
+			if err == nil {
+				counts[id] = data
+				continue
+			}
+			logging.Info(err)
+		}
+		misses = append(misses, id)
+	}
+
+	if len(misses) == 0 {
+		return counts, nil
+	}
+
+	stored, err := models.GetArticleReactionCounts(misses)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, data := range stored {
+		counts[id] = data
+		cache := cache_service.Reaction{ArticleID: id}
+		gredis.HSetAll(cache.GetReactionKey(), data, cacheExpire)
+	}
+
+	return counts, nil
+}
+
+// Reconcile recounts the articles whose counters changed since the last run and
+// overwrites the Redis counters with the totals stored in MySQL
+func Reconcile() error {
+	cache := cache_service.Reaction{}
+	members, err := gredis.SPopAll(cache.GetDirtyKey())
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	for _, member := range members {
+		id, err := strconv.Atoi(member)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	stored, err := models.GetArticleReactionCounts(ids)
+	if err != nil {
+		gredis.SAdd(cache.GetDirtyKey(), redisArgs(ids)...)
+		return err
+	}
+
+	for id, data := range stored {
+		cache := cache_service.Reaction{ArticleID: id}
+		if err := gredis.HSetAll(cache.GetReactionKey(), data, cacheExpire); err != nil {
+			logging.Warn(err)
+		}
+	}
+
+	return nil
+}
+
+func redisArgs(ids []int) []interface{} {
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	return args
+}
@@ -0,0 +1,212 @@
+package related_service
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/tfidf"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+// minCacheExpire is the least time in seconds related articles are cached,
+// so that they do not stay forever when the refresh job does not run
+const minCacheExpire = 3600
+
+type RelatedArticle struct {
+	ID            int     `json:"id"`
+	TagID         int     `json:"tag_id"`
+	Title         string  `json:"title"`
+	Desc          string  `json:"desc"`
+	CoverImageUrl string  `json:"cover_image_url"`
+	Score         float64 `json:"score"`
+}
+
+type Related struct {
+	ArticleID int
+	Viewer    string
+}
+
+// RecordView remembers the article in the viewer's history and counts it as
+// viewed together with every article already in that history
+func (r *Related) RecordView() error {
+	cache := cache_service.Related{ArticleID: r.ArticleID, Viewer: r.Viewer}
+	viewsKey := cache.GetViewsKey()
+
+	history, err := gredis.LRange(viewsKey, 0, setting.RelatedSetting.CoViewHistory-1)
+	if err != nil {
+		return err
+	}
+
+	current := strconv.Itoa(r.ArticleID)
+	for _, id := range history {
+		if id == current {
+			return nil
+		}
+	}
+
+	expire := setting.RelatedSetting.CoViewExpire
+	for _, id := range history {
+		other, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+
+		if err := gredis.ZIncrBy(cache.GetCoViewKey(), other, 1, expire); err != nil {
+			return err
+		}
+		otherCache := cache_service.Related{ArticleID: other}
+		if err := gredis.ZIncrBy(otherCache.GetCoViewKey(), r.ArticleID, 1, expire); err != nil {
+			return err
+		}
+	}
+
+	return gredis.LPushTrim(viewsKey, r.ArticleID, setting.RelatedSetting.CoViewHistory, expire)
+}
+
+// Get returns the precomputed related articles, falling back to articles of the
+// same tag until the background job has covered this article
+func (r *Related) Get() ([]RelatedArticle, error) {
+	var related []RelatedArticle
+
+	cache := cache_service.Related{ArticleID: r.ArticleID}
+	key := cache.GetRelatedKey()
+	if gredis.Exists(key) {
+		data, err := gredis.Get(key)
+		if err != nil {
+			logging.Info(err)
+		} else {
+			json.Unmarshal(data, &related)
+			return related, nil
+		}
+	}
+
+	article, err := models.GetArticle(r.ArticleID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := setting.RelatedSetting.Limit
+	articles, err := models.GetArticles(0, limit+1, map[string]interface{}{
+		"deleted_on": 0,
+		"state":      1,
+		"tag_id":     article.TagID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	related = []RelatedArticle{}
+	for _, a := range articles {
+		if a.ID != r.ArticleID && len(related) < limit {
+			related = append(related, newRelatedArticle(a, setting.RelatedSetting.TagWeight))
+		}
+	}
+
+	return related, nil
+}
+
+// Refresh recomputes the related articles of every published article and caches them
+func Refresh() error {
+	articles, err := models.GetAllArticles(map[string]interface{}{
+		"deleted_on": 0,
+		"state":      1,
+	})
+	if err != nil {
+		return err
+	}
+
+	documents := make([][]string, len(articles))
+	for i, a := range articles {
+		// the title is repeated so that it outweighs the body
+		documents[i] = tfidf.Tokenize(strings.Join([]string{a.Title, a.Title, a.Desc, a.Content}, " "))
+	}
+	corpus := tfidf.NewCorpus(documents)
+	vectors := make([]tfidf.Vector, len(articles))
+	for i := range documents {
+		vectors[i] = corpus.Vector(documents[i])
+	}
+
+	expire := int(setting.RelatedSetting.RefreshInterval.Seconds()) * 2
+	if expire < minCacheExpire {
+		expire = minCacheExpire
+	}
+	for i, a := range articles {
+		coViews, maxCoView := getCoViews(a.ID)
+
+		related := make([]RelatedArticle, 0, len(articles))
+		for j, b := range articles {
+			if i == j {
+				continue
+			}
+
+			var score float64
+			if a.TagID == b.TagID {
+				score += setting.RelatedSetting.TagWeight
+			}
+			score += setting.RelatedSetting.TextWeight * tfidf.Cosine(vectors[i], vectors[j])
+			if maxCoView > 0 {
+				score += setting.RelatedSetting.CoViewWeight * float64(coViews[b.ID]) / float64(maxCoView)
+			}
+
+			if score > 0 {
+				related = append(related, newRelatedArticle(b, score))
+			}
+		}
+
+		sort.SliceStable(related, func(x, y int) bool {
+			return related[x].Score > related[y].Score
+		})
+		if len(related) > setting.RelatedSetting.Limit {
+			related = related[:setting.RelatedSetting.Limit]
+		}
+
+		cache := cache_service.Related{ArticleID: a.ID}
+		if err := gredis.Set(cache.GetRelatedKey(), related, expire); err != nil {
+			logging.Warn(err)
+		}
+	}
+
+	return nil
+}
+
+// getCoViews returns how often other articles were viewed together with the article
+func getCoViews(articleID int) (map[int]int, int) {
+	cache := cache_service.Related{ArticleID: articleID}
+	data, err := gredis.ZRevRangeWithScores(cache.GetCoViewKey(), 0, 99)
+	if err != nil {
+		logging.Info(err)
+		return nil, 0
+	}
+
+	var max int
+	coViews := make(map[int]int, len(data))
+	for member, count := range data {
+		id, err := strconv.Atoi(member)
+		if err != nil {
+			continue
+		}
+		coViews[id] = count
+		if count > max {
+			max = count
+		}
+	}
+
+	return coViews, max
+}
+
+func newRelatedArticle(a *models.Article, score float64) RelatedArticle {
+	return RelatedArticle{
+		ID:            a.ID,
+		TagID:         a.TagID,
+		Title:         a.Title,
+		Desc:          a.Desc,
+		CoverImageUrl: a.CoverImageUrl,
+		Score:         score,
+	}
+}
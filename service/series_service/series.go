@@ -0,0 +1,142 @@
+package series_service
+
+import (
+	"github.com/EDDYCJY/go-gin-example/models"
+)
+
+type Series struct {
+	ID         int
+	Title      string
+	Desc       string
+	State      int
+	CreatedBy  string
+	ModifiedBy string
+
+	ArticleID  int
+	Position   int
+	ArticleIDs []int
+
+	PageNum  int
+	PageSize int
+}
+
+func (s *Series) Add() error {
+	return models.AddSeries(map[string]interface{}{
+		"title":      s.Title,
+		"desc":       s.Desc,
+		"created_by": s.CreatedBy,
+		"state":      s.State,
+	})
+}
+
+func (s *Series) Edit() error {
+	return models.EditSeries(s.ID, map[string]interface{}{
+		"title":       s.Title,
+		"desc":        s.Desc,
+		"state":       s.State,
+		"modified_by": s.ModifiedBy,
+	})
+}
+
+func (s *Series) Get() (*models.Series, error) {
+	return models.GetSeries(s.ID)
+}
+
+func (s *Series) GetAll() ([]*models.Series, error) {
+	return models.GetSeriesList(s.PageNum, s.PageSize, s.getMaps())
+}
+
+func (s *Series) Delete() error {
+	return models.DeleteSeries(s.ID)
+}
+
+func (s *Series) ExistByID() (bool, error) {
+	return models.ExistSeriesByID(s.ID)
+}
+
+func (s *Series) Count() (int, error) {
+	return models.GetSeriesTotal(s.getMaps())
+}
+
+// GetArticles returns the parts of the series in reading order
+func (s *Series) GetArticles() ([]*models.SeriesArticle, error) {
+	return models.GetSeriesArticles(s.ID)
+}
+
+// InArticleSeries reports which series the article already belongs to, 0 for none
+func (s *Series) InArticleSeries() (int, error) {
+	part, err := models.GetSeriesArticleByArticleID(s.ArticleID)
+	if err != nil || part == nil {
+		return 0, err
+	}
+
+	return part.SeriesID, nil
+}
+
+// InsertArticle inserts the article at Position, appending it when Position is 0
+func (s *Series) InsertArticle() error {
+	return models.InsertSeriesArticle(s.ID, s.ArticleID, s.Position)
+}
+
+// RemoveArticle takes the article out of its series
+func (s *Series) RemoveArticle() error {
+	return models.RemoveSeriesArticle(s.ArticleID)
+}
+
+// SortArticles reorders the series to follow ArticleIDs
+func (s *Series) SortArticles() error {
+	return models.SortSeriesArticles(s.ID, s.ArticleIDs)
+}
+
+// GetNav returns the series navigation of an article, nil if it is not part of a series
+func GetNav(articleID int) (*models.SeriesNav, error) {
+	part, err := models.GetSeriesArticleByArticleID(articleID)
+	if err != nil || part == nil {
+		return nil, err
+	}
+
+	series, err := models.GetSeries(part.SeriesID)
+	if err != nil || series.ID == 0 {
+		return nil, err
+	}
+
+	total, err := models.GetSeriesArticleTotal(part.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	nav := &models.SeriesNav{
+		ID:       series.ID,
+		Title:    series.Title,
+		Position: part.Position,
+		Total:    total,
+	}
+
+	prev, err := models.GetSeriesArticleByPosition(part.SeriesID, part.Position-1)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		nav.Prev = &models.SeriesNavLink{ID: prev.ArticleID, Title: prev.Article.Title}
+	}
+
+	next, err := models.GetSeriesArticleByPosition(part.SeriesID, part.Position+1)
+	if err != nil {
+		return nil, err
+	}
+	if next != nil {
+		nav.Next = &models.SeriesNavLink{ID: next.ArticleID, Title: next.Article.Title}
+	}
+
+	return nav, nil
+}
+
+func (s *Series) getMaps() map[string]interface{} {
+	maps := make(map[string]interface{})
+	maps["deleted_on"] = 0
+	if s.State >= 0 {
+		maps["state"] = s.State
+	}
+
+	return maps
+}
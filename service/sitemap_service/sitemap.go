@@ -0,0 +1,374 @@
+package sitemap_service
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/file"
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/link"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/sitemap"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+const (
+	SECTION_TAGS     = "tags"
+	SECTION_ARTICLES = "articles"
+
+	INDEX_NAME = "sitemap" + sitemap.EXT
+)
+
+// chunkSize is how many IDs a part covers; the first tag part also carries the home page
+var chunkSize = map[string]int{
+	SECTION_TAGS:     sitemap.MaxURLs - 1,
+	SECTION_ARTICLES: sitemap.MaxURLs,
+}
+
+var publishedMaps = map[string]interface{}{
+	"deleted_on": 0,
+	"state":      1,
+}
+
+// MarkArticle queues the sitemap part holding the article for regeneration
+func MarkArticle(id int) {
+	mark(SECTION_ARTICLES, id)
+}
+
+// MarkTag queues the sitemap part holding the tag for regeneration
+func MarkTag(id int) {
+	mark(SECTION_TAGS, id)
+}
+
+func mark(section string, id int) {
+	cache := cache_service.Sitemap{}
+	if err := gredis.SAdd(cache.GetDirtyKey(), partName(section, chunkOf(section, id))); err != nil {
+		logging.Info(err)
+	}
+}
+
+// GetIndexFullPath returns the path of sitemap.xml, generating the sitemap on first use
+func GetIndexFullPath() (string, error) {
+	src := sitemap.GetSitemapFullPath() + INDEX_NAME
+	if file.CheckNotExist(src) {
+		if err := Generate(); err != nil {
+			return "", err
+		}
+	}
+
+	return src, nil
+}
+
+// Refresh regenerates the sitemap when articles or tags changed since the last run.
+// The changes are only cleared once the sitemap was written, so that a failed
+// run is retried with them
+func Refresh() error {
+	cache := cache_service.Sitemap{}
+	dirty, err := gredis.SMembers(cache.GetDirtyKey())
+	if err != nil {
+		return err
+	}
+	if len(dirty) == 0 && !file.CheckNotExist(sitemap.GetSitemapFullPath()+INDEX_NAME) {
+		return nil
+	}
+
+	if err := refresh(dirty); err != nil {
+		return err
+	}
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, 0, len(dirty))
+	for _, name := range dirty {
+		members = append(members, name)
+	}
+	return gredis.SRem(cache.GetDirtyKey(), members...)
+}
+
+func refresh(dirty []string) error {
+	split, err := needsIndex()
+	if err != nil {
+		return err
+	}
+
+	// small sites are rebuilt as a single file; large ones only rewrite the parts that changed
+	if !split || file.CheckNotExist(sitemap.GetSitemapFullPath()+partName(SECTION_TAGS, 1)) {
+		return Generate()
+	}
+
+	dir := sitemap.GetSitemapFullPath()
+	for _, name := range dirty {
+		section, n, ok := parsePartName(name)
+		if !ok {
+			continue
+		}
+		if err := writePart(dir, section, n); err != nil {
+			return err
+		}
+	}
+
+	return writeIndex(dir)
+}
+
+// Generate rebuilds the whole sitemap
+func Generate() error {
+	return GenerateTo(sitemap.GetSitemapFullPath())
+}
+
+// GenerateTo rebuilds the whole sitemap into dir, which receives sitemap.xml and its parts
+func GenerateTo(dir string) error {
+	if err := file.IsNotExistMkDir(dir); err != nil {
+		return err
+	}
+
+	split, err := needsIndex()
+	if err != nil {
+		return err
+	}
+
+	if err := removeParts(dir); err != nil {
+		return err
+	}
+
+	if !split {
+		var urls []sitemap.URL
+		for _, section := range []string{SECTION_TAGS, SECTION_ARTICLES} {
+			sectionURLs, err := getURLs(section, 0, int(^uint(0)>>1))
+			if err != nil {
+				return err
+			}
+			urls = append(urls, sectionURLs...)
+		}
+
+		body, err := sitemap.MarshalURLSet(urls)
+		if err != nil {
+			return err
+		}
+		return writeFile(dir, INDEX_NAME, body)
+	}
+
+	for _, section := range []string{SECTION_TAGS, SECTION_ARTICLES} {
+		maxID, err := getMaxID(section)
+		if err != nil {
+			return err
+		}
+		for n := 1; n <= chunkOf(section, maxID); n++ {
+			if err := writePart(dir, section, n); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writeIndex(dir)
+}
+
+// needsIndex reports whether the site has outgrown a single sitemap file
+func needsIndex() (bool, error) {
+	articles, err := models.GetArticleTotal(publishedMaps)
+	if err != nil {
+		return false, err
+	}
+	tags, err := models.GetTagTotal(publishedMaps)
+	if err != nil {
+		return false, err
+	}
+
+	return articles+tags+1 > sitemap.MaxURLs, nil
+}
+
+// writePart rewrites one part, removing it once it has no pages left
+func writePart(dir, section string, n int) error {
+	size := chunkSize[section]
+	urls, err := getURLs(section, (n-1)*size, n*size)
+	if err != nil {
+		return err
+	}
+
+	name := partName(section, n)
+	if len(urls) == 0 {
+		os.Remove(dir + name)
+		return nil
+	}
+
+	body, err := sitemap.MarshalURLSet(urls)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(dir, name, body)
+}
+
+// writeIndex lists every part present in dir in sitemap.xml
+func writeIndex(dir string) error {
+	names, err := listParts(dir)
+	if err != nil {
+		return err
+	}
+
+	var entries []sitemap.Entry
+	for _, name := range names {
+		info, err := os.Stat(dir + name)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, sitemap.Entry{
+			Loc:     sitemap.GetSitemapFullUrl(name),
+			LastMod: sitemap.FormatTime(info.ModTime()),
+		})
+	}
+
+	body, err := sitemap.MarshalIndex(entries)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(dir, INDEX_NAME, body)
+}
+
+func getURLs(section string, minID, maxID int) ([]sitemap.URL, error) {
+	var urls []sitemap.URL
+
+	switch section {
+	case SECTION_TAGS:
+		if minID == 0 {
+			urls = append(urls, sitemap.NewURL(link.GetHomeFullUrl(), 0))
+		}
+
+		tags, err := models.GetTagsByIDRange(minID, maxID, publishedMaps)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			urls = append(urls, sitemap.NewURL(link.GetTagFullUrl(tag.ID), lastMod(tag.CreatedOn, tag.ModifiedOn)))
+		}
+	case SECTION_ARTICLES:
+		articles, err := models.GetArticlesByIDRange(minID, maxID, publishedMaps)
+		if err != nil {
+			return nil, err
+		}
+		for _, article := range articles {
+			urls = append(urls, sitemap.NewURL(
+				link.GetArticleFullUrl(article.ID),
+				lastMod(article.CreatedOn, article.ModifiedOn),
+				article.CoverImageUrl,
+			))
+		}
+	}
+
+	return urls, nil
+}
+
+func getMaxID(section string) (int, error) {
+	if section == SECTION_TAGS {
+		return models.GetMaxTagID()
+	}
+
+	return models.GetMaxArticleID()
+}
+
+func lastMod(createdOn, modifiedOn int) int {
+	if modifiedOn > createdOn {
+		return modifiedOn
+	}
+
+	return createdOn
+}
+
+func chunkOf(section string, id int) int {
+	if id < 1 {
+		return 1
+	}
+
+	return (id-1)/chunkSize[section] + 1
+}
+
+func partName(section string, n int) string {
+	return "sitemap-" + section + "-" + strconv.Itoa(n) + sitemap.EXT
+}
+
+func parsePartName(name string) (string, int, bool) {
+	parts := strings.Split(strings.TrimSuffix(name, sitemap.EXT), "-")
+	if len(parts) != 3 || parts[0] != "sitemap" || chunkSize[parts[1]] == 0 {
+		return "", 0, false
+	}
+
+	n, err := strconv.Atoi(parts[2])
+	if err != nil || n < 1 {
+		return "", 0, false
+	}
+
+	return parts[1], n, true
+}
+
+func listParts(dir string) ([]string, error) {
+	matches, err := filepath.Glob(dir + "sitemap-*" + sitemap.EXT)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, filepath.Base(match))
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func removeParts(dir string) error {
+	names, err := listParts(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := os.Remove(dir + name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeFile replaces a file atomically so crawlers never fetch a half-written sitemap
+func writeFile(dir, name string, body []byte) error {
+	src := dir + name
+	tmp := src + ".tmp"
+	if err := ioutil.WriteFile(tmp, body, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, src)
+}
+
+// GetRobots renders robots.txt from the configuration, pointing crawlers at the sitemap
+func GetRobots() []byte {
+	var buf bytes.Buffer
+
+	robots := setting.RobotsSetting
+	buf.WriteString("User-agent: " + robots.UserAgent + "\n")
+	for _, path := range robots.Allow {
+		if path != "" {
+			buf.WriteString("Allow: " + path + "\n")
+		}
+	}
+	for _, path := range robots.Disallow {
+		if path != "" {
+			buf.WriteString("Disallow: " + path + "\n")
+		}
+	}
+	if robots.CrawlDelay > 0 {
+		buf.WriteString("Crawl-delay: " + strconv.Itoa(robots.CrawlDelay) + "\n")
+	}
+	buf.WriteString("\nSitemap: " + setting.AppSetting.PrefixUrl + "/" + INDEX_NAME + "\n")
+
+	return buf.Bytes()
+}
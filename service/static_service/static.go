@@ -0,0 +1,395 @@
+package static_service
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/feed"
+	"github.com/EDDYCJY/go-gin-example/pkg/file"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/pkg/theme"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/pkg/util"
+	"github.com/EDDYCJY/go-gin-example/service/article_service"
+	"github.com/EDDYCJY/go-gin-example/service/feed_service"
+	"github.com/EDDYCJY/go-gin-example/service/related_service"
+	"github.com/EDDYCJY/go-gin-example/service/sitemap_service"
+	"github.com/EDDYCJY/go-gin-example/service/tag_service"
+)
+
+// MANIFEST_NAME records the checksum of every exported file so rebuilds can skip unchanged ones
+const MANIFEST_NAME = ".manifest.json"
+
+var feedFormats = []string{feed.RSS, feed.ATOM, feed.JSON}
+
+// Report counts what an export did to the output directory
+type Report struct {
+	Written   int `json:"written"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+}
+
+type Export struct {
+	Dir string
+
+	theme    *theme.Theme
+	manifest map[string]string
+	seen     map[string]bool
+	report   Report
+}
+
+// Run writes the whole published blog into Dir, rewriting only the files whose
+// content changed since the previous run and removing pages that disappeared
+func (e *Export) Run() (*Report, error) {
+	var err error
+
+	e.Dir = strings.TrimSuffix(e.Dir, "/") + "/"
+	if err = file.IsNotExistMkDir(e.Dir); err != nil {
+		return nil, err
+	}
+
+	e.theme, err = theme.New(theme.GetThemeFullPath())
+	if err != nil {
+		return nil, err
+	}
+
+	e.seen = make(map[string]bool)
+	e.manifest = make(map[string]string)
+	if data, err := ioutil.ReadFile(e.Dir + MANIFEST_NAME); err == nil {
+		json.Unmarshal(data, &e.manifest)
+	}
+
+	steps := []func() error{
+		e.exportArticles,
+		e.exportTags,
+		e.exportArchive,
+		e.exportSitemap,
+		e.exportAssets,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	for path := range e.manifest {
+		if !e.seen[path] {
+			os.Remove(e.Dir + path)
+			delete(e.manifest, path)
+			e.report.Removed++
+		}
+	}
+
+	data, err := json.MarshalIndent(e.manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(e.Dir+MANIFEST_NAME, data, 0644); err != nil {
+		return nil, err
+	}
+
+	return &e.report, nil
+}
+
+// exportArticles writes the home pages, every article page and the author pages and feeds
+func (e *Export) exportArticles() error {
+	articleService := article_service.Article{TagID: -1, State: 1}
+	if err := e.exportList("", "index", "", &articleService); err != nil {
+		return err
+	}
+	if err := e.exportFeeds("feed/", &feed_service.Feed{}); err != nil {
+		return err
+	}
+
+	articles, err := articleService.ListAll()
+	if err != nil {
+		return err
+	}
+
+	authors := make(map[string]bool)
+	for _, a := range articles {
+		if err := e.exportArticle(a.ID); err != nil {
+			return err
+		}
+		authors[a.CreatedBy] = true
+	}
+
+	for author := range authors {
+		// an author name becomes a directory name, so skip anything that could escape it
+		if author == "" || author == "." || author == ".." || strings.ContainsAny(author, `/\`) {
+			continue
+		}
+
+		authorService := article_service.Article{TagID: -1, State: 1, CreatedBy: author}
+		if err := e.exportList("authors/"+author+"/", "tag", author, &authorService); err != nil {
+			return err
+		}
+		if err := e.exportFeeds("authors/"+author+"/feed/", &feed_service.Feed{Author: author}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Export) exportArticle(id int) error {
+	articleService := article_service.Article{ID: id}
+	article, err := articleService.Get()
+	if err != nil {
+		return err
+	}
+
+	relatedService := related_service.Related{ArticleID: id}
+	related, err := relatedService.Get()
+	if err != nil {
+		logging.Info(err)
+	}
+
+	return e.renderPage("articles/"+strconv.Itoa(id)+"/index.html", "article", &theme.Page{
+		Title: article.Title,
+		Data: map[string]interface{}{
+			"Article": article,
+			"Related": related,
+		},
+	})
+}
+
+// exportTags writes the pages and feeds of every published tag
+func (e *Export) exportTags() error {
+	tagService := tag_service.Tag{State: 1}
+	tags, err := tagService.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		base := "tags/" + strconv.Itoa(tag.ID) + "/"
+		articleService := article_service.Article{TagID: tag.ID, State: 1}
+		if err := e.exportList(base, "tag", tag.Name, &articleService); err != nil {
+			return err
+		}
+		if err := e.exportFeeds(base+"feed/", &feed_service.Feed{TagID: tag.ID}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Export) exportArchive() error {
+	articleService := article_service.Article{TagID: -1, State: 1}
+	months, err := articleService.GetArchive()
+	if err != nil {
+		return err
+	}
+
+	return e.renderPage("archive/index.html", "archive", &theme.Page{Title: "Archive", Data: months})
+}
+
+// exportList writes every page of an article listing under base, as base/page/N/index.html
+func (e *Export) exportList(base, name, title string, articleService *article_service.Article) error {
+	total, err := articleService.Count()
+	if err != nil {
+		return err
+	}
+
+	pageSize := setting.AppSetting.PageSize
+	for n := 1; n == 1 || (n-1)*pageSize < total; n++ {
+		articleService.PageNum = (n - 1) * pageSize
+		articleService.PageSize = pageSize
+		articles, err := articleService.GetLatest()
+		if err != nil {
+			return err
+		}
+
+		page := &theme.Page{Title: title, Data: articles, Page: n}
+		if n > 1 {
+			page.PrevUrl = pageUrl(base, n-1)
+		}
+		if n*pageSize < total {
+			page.NextUrl = pageUrl(base, n+1)
+		}
+
+		path := base + "index.html"
+		if n > 1 {
+			path = base + "page/" + strconv.Itoa(n) + "/index.html"
+		}
+		if err := e.renderPage(path, name, page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Export) exportFeeds(base string, feedService *feed_service.Feed) error {
+	doc, err := feedService.Build()
+	if err != nil {
+		return err
+	}
+
+	for _, format := range feedFormats {
+		body, err := doc.Render(format)
+		if err != nil {
+			return err
+		}
+		if err := e.write(base+feed.FileName(format), body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportSitemap writes robots.txt, sitemap.xml and, for large sites, the parts under sitemaps/
+func (e *Export) exportSitemap() error {
+	tmp, err := ioutil.TempDir("", "sitemap")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := sitemap_service.GenerateTo(tmp + "/"); err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(tmp)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		body, err := ioutil.ReadFile(filepath.Join(tmp, f.Name()))
+		if err != nil {
+			return err
+		}
+
+		path := "sitemaps/" + f.Name()
+		if f.Name() == sitemap_service.INDEX_NAME {
+			path = f.Name()
+		}
+		if err := e.write(path, body); err != nil {
+			return err
+		}
+	}
+
+	return e.write("robots.txt", sitemap_service.GetRobots())
+}
+
+// exportAssets copies the uploaded images and the theme's static files
+func (e *Export) exportAssets() error {
+	if err := e.copyStorage(storage.Images, upload.GetImagePath()); err != nil {
+		return err
+	}
+
+	return e.copyDir(theme.GetStaticFullPath(), "static/")
+}
+
+func (e *Export) copyStorage(src storage.Storage, dst string) error {
+	files, err := src.List("")
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		r, err := src.Get(f.Name)
+		if err != nil {
+			return err
+		}
+		body, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+		if err := e.write(dst+f.Name, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Export) copyDir(src, dst string) error {
+	if file.CheckNotExist(src) {
+		return nil
+	}
+
+	var paths []string
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(paths)
+	for _, path := range paths {
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := e.write(dst+filepath.ToSlash(rel), body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Export) renderPage(path, name string, page *theme.Page) error {
+	page.SiteTitle = setting.FrontendSetting.Title
+
+	var buf bytes.Buffer
+	if err := e.theme.Render(&buf, name, page); err != nil {
+		return err
+	}
+
+	return e.write(path, buf.Bytes())
+}
+
+// write stores a file unless the previous export already produced the same content
+func (e *Export) write(path string, body []byte) error {
+	e.seen[path] = true
+
+	sum := util.EncodeMD5(string(body))
+	if e.manifest[path] == sum && !file.CheckNotExist(e.Dir+path) {
+		e.report.Unchanged++
+		return nil
+	}
+
+	if err := file.IsNotExistMkDir(filepath.Dir(e.Dir + path)); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(e.Dir+path, body, 0644); err != nil {
+		return err
+	}
+
+	e.manifest[path] = sum
+	e.report.Written++
+	return nil
+}
+
+func pageUrl(base string, n int) string {
+	if n == 1 {
+		return setting.AppSetting.PrefixUrl + "/" + base
+	}
+
+	return setting.AppSetting.PrefixUrl + "/" + base + "page/" + strconv.Itoa(n) + "/"
+}
@@ -0,0 +1,379 @@
+package tag_service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/export"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+)
+
+const (
+	FORMAT_XLSX = "xlsx"
+	FORMAT_CSV  = "csv"
+	FORMAT_JSON = "json"
+
+	SHEET_NAME = "标签信息"
+)
+
+var (
+	ErrUnknownFormat    = errors.New("unknown tag table format")
+	ErrInvalidDelimiter = errors.New("invalid csv delimiter")
+	ErrMalformedFile    = errors.New("tag file cannot be read")
+
+	utf8BOM = []byte("\xef\xbb\xbf")
+)
+
+type column struct {
+	Key   string
+	Title string
+	Value func(tag *models.Tag) int
+	Text  func(tag *models.Tag) string
+}
+
+// tagColumns is the one schema of a tag table in every format: Excel sheets are
+// headed by the titles, CSV and JSON by the keys, and an import accepts either
+var tagColumns = []column{
+	{Key: "id", Title: "ID", Value: func(t *models.Tag) int { return t.ID }},
+	{Key: "name", Title: "名称", Text: func(t *models.Tag) string { return t.Name }},
+	{Key: "state", Title: "状态", Value: func(t *models.Tag) int { return t.State }},
+	{Key: "created_by", Title: "创建人", Text: func(t *models.Tag) string { return t.CreatedBy }},
+	{Key: "created_on", Title: "创建时间", Value: func(t *models.Tag) int { return t.CreatedOn }},
+	{Key: "modified_by", Title: "修改人", Text: func(t *models.Tag) string { return t.ModifiedBy }},
+	{Key: "modified_on", Title: "修改时间", Value: func(t *models.Tag) int { return t.ModifiedOn }},
+}
+
+var formatExts = map[string]string{
+	FORMAT_XLSX: export.EXT,
+	FORMAT_CSV:  export.CSV_EXT,
+	FORMAT_JSON: export.JSON_EXT,
+}
+
+var formatContentTypes = map[string]string{
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": FORMAT_XLSX,
+	"text/csv":                  FORMAT_CSV,
+	"application/csv":           FORMAT_CSV,
+	"text/tab-separated-values": FORMAT_CSV,
+	"application/json":          FORMAT_JSON,
+}
+
+// Format is how a tag table is encoded
+type Format struct {
+	Name      string
+	Delimiter rune
+	BOM       bool
+}
+
+// NewFormat returns the named format, with the CSV options taken from the settings
+func NewFormat(name string) (*Format, error) {
+	if _, ok := formatExts[name]; !ok {
+		return nil, ErrUnknownFormat
+	}
+
+	delimiter, err := ParseDelimiter(setting.CsvSetting.Delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Format{Name: name, Delimiter: delimiter, BOM: setting.CsvSetting.BOM}, nil
+}
+
+// GetFormatByContentType maps a media type, as found in Content-Type, to a format name
+func GetFormatByContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	return formatContentTypes[mediaType]
+}
+
+// GetFormatByFileName maps a file extension to a format name
+func GetFormatByFileName(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	for format, formatExt := range formatExts {
+		if ext == formatExt {
+			return format
+		}
+	}
+	if ext == ".tsv" {
+		return FORMAT_CSV
+	}
+
+	return ""
+}
+
+// ParseDelimiter reads a CSV delimiter, a single character or "tab"
+func ParseDelimiter(s string) (rune, error) {
+	switch s {
+	case "":
+		return ',', nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, ErrInvalidDelimiter
+	}
+
+	return r, nil
+}
+
+func (f *Format) Ext() string {
+	return formatExts[f.Name]
+}
+
+// tableWriter writes a tag table one row at a time
+type tableWriter interface {
+	Write(tag *models.Tag) error
+	Close() error
+}
+
+func (f *Format) newWriter(w io.Writer) (tableWriter, error) {
+	switch f.Name {
+	case FORMAT_CSV:
+		return f.newCSVWriter(w)
+	case FORMAT_JSON:
+		return newJSONWriter(w), nil
+	}
+
+	return newXlsxWriter(w)
+}
+
+// decode reads a table into rows of cells, the first row holding the header
+func (f *Format) decode(r io.Reader) ([][]string, error) {
+	var (
+		rows [][]string
+		err  error
+	)
+	switch f.Name {
+	case FORMAT_CSV:
+		rows, err = f.decodeCSV(r)
+	case FORMAT_JSON:
+		rows, err = decodeJSON(r)
+	default:
+		rows, err = decodeXlsx(r)
+	}
+
+	if err != nil {
+		logging.Warn(err)
+		return nil, ErrMalformedFile
+	}
+	return rows, nil
+}
+
+type xlsxWriter struct {
+	*export.XlsxWriter
+}
+
+func newXlsxWriter(w io.Writer) (tableWriter, error) {
+	xw, err := export.NewXlsxWriter(w, SHEET_NAME)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]string, len(tagColumns))
+	for i, c := range tagColumns {
+		header[i] = c.Title
+	}
+	if err := xw.WriteRow(header); err != nil {
+		return nil, err
+	}
+
+	return xlsxWriter{xw}, nil
+}
+
+func (x xlsxWriter) Write(tag *models.Tag) error {
+	return x.WriteRow(rowOf(tag))
+}
+
+// newSheet writes rows into a workbook with a single tag sheet. Cells are stored
+// as inline strings, since tealeg/xlsx writes an empty shared string that
+// excelize reads back as the first string of the sheet
+func newSheet(rows [][]string) *excelize.File {
+	xlsx := excelize.NewFile()
+	xlsx.SetSheetName("Sheet1", SHEET_NAME)
+	for i, row := range rows {
+		for j, value := range row {
+			xlsx.SetCellStr(SHEET_NAME, cellName(j, i), value)
+		}
+	}
+
+	return xlsx
+}
+
+// cellName names the cell at a zero based column and row, as in "B3"
+func cellName(col, row int) string {
+	return excelize.ToAlphaString(col) + strconv.Itoa(row+1)
+}
+
+func decodeXlsx(r io.Reader) ([][]string, error) {
+	xlsx, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := SHEET_NAME
+	if xlsx.GetSheetIndex(sheet) == 0 {
+		sheet = xlsx.GetSheetName(1)
+	}
+
+	return xlsx.GetRows(sheet), nil
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (f *Format) newCSVWriter(w io.Writer) (tableWriter, error) {
+	if f.BOM {
+		if _, err := w.Write(utf8BOM); err != nil {
+			return nil, err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = f.Delimiter
+
+	header := make([]string, len(tagColumns))
+	for i, c := range tagColumns {
+		header[i] = c.Key
+	}
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+
+	return csvWriter{cw}, nil
+}
+
+func (c csvWriter) Write(tag *models.Tag) error {
+	return c.w.Write(rowOf(tag))
+}
+
+func (c csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (f *Format) decodeCSV(r io.Reader) ([][]string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	cr.Comma = f.Delimiter
+	cr.FieldsPerRecord = -1
+
+	return cr.ReadAll()
+}
+
+// jsonWriter writes an indented array of objects keyed like tagColumns
+type jsonWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+func newJSONWriter(w io.Writer) tableWriter {
+	return &jsonWriter{w: bufio.NewWriter(w)}
+}
+
+func (j *jsonWriter) Write(tag *models.Tag) error {
+	item := make(map[string]interface{}, len(tagColumns))
+	for _, c := range tagColumns {
+		if c.Value != nil {
+			item[c.Key] = c.Value(tag)
+		} else {
+			item[c.Key] = c.Text(tag)
+		}
+	}
+
+	data, err := json.MarshalIndent(item, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	if j.count == 0 {
+		j.w.WriteString("[\n  ")
+	} else {
+		j.w.WriteString(",\n  ")
+	}
+	j.count++
+
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if j.count == 0 {
+		j.w.WriteString("[]\n")
+	} else {
+		j.w.WriteString("\n]\n")
+	}
+
+	return j.w.Flush()
+}
+
+// decodeJSON reads an array of objects keyed like tagColumns
+func decodeJSON(r io.Reader) ([][]string, error) {
+	var items []map[string]interface{}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&items); err != nil {
+		return nil, err
+	}
+
+	header := make([]string, len(tagColumns))
+	for i, c := range tagColumns {
+		header[i] = c.Key
+	}
+
+	rows := [][]string{header}
+	for _, item := range items {
+		row := make([]string, len(tagColumns))
+		for i, c := range tagColumns {
+			switch v := item[c.Key].(type) {
+			case nil:
+			case string:
+				row[i] = v
+			case json.Number:
+				row[i] = v.String()
+			default:
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func rowOf(tag *models.Tag) []string {
+	row := make([]string, len(tagColumns))
+	for i, c := range tagColumns {
+		if c.Value != nil {
+			row[i] = strconv.Itoa(c.Value(tag))
+		} else {
+			row[i] = c.Text(tag)
+		}
+	}
+
+	return row
+}
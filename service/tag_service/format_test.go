@@ -0,0 +1,118 @@
+package tag_service
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+)
+
+func TestFormatRoundTrip(t *testing.T) {
+	tags := []*models.Tag{
+		{Model: models.Model{ID: 1, CreatedOn: 100}, Name: "Go", State: 1, CreatedBy: "admin"},
+		{Model: models.Model{ID: 2, ModifiedOn: 200}, Name: "逗号, \"引号\"", State: 0, CreatedBy: "editor", ModifiedBy: "admin"},
+	}
+
+	formats := []*Format{
+		{Name: FORMAT_XLSX},
+		{Name: FORMAT_CSV, Delimiter: ','},
+		{Name: FORMAT_CSV, Delimiter: '\t', BOM: true},
+		{Name: FORMAT_CSV, Delimiter: ';', BOM: true},
+		{Name: FORMAT_JSON},
+	}
+	for _, format := range formats {
+		t.Run(format.Name+" "+string(format.Delimiter), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := format.newWriter(&buf)
+			if err != nil {
+				t.Fatalf("newWriter() err = %v", err)
+			}
+			for _, tag := range tags {
+				if err := w.Write(tag); err != nil {
+					t.Fatalf("Write() err = %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() err = %v", err)
+			}
+
+			rows, err := format.decode(&buf)
+			if err != nil {
+				t.Fatalf("decode() err = %v", err)
+			}
+			if len(rows) != len(tags)+1 {
+				t.Fatalf("decode() got %d rows, want %d", len(rows), len(tags)+1)
+			}
+
+			index := mapColumns(rows[0])
+			for i, tag := range tags {
+				row := rows[i+1]
+				if len(row) < len(tagColumns) {
+					t.Fatalf("row %d has %d cells, want %d", i+1, len(row), len(tagColumns))
+				}
+				if !reflect.DeepEqual(row[:len(tagColumns)], rowOf(tag)) {
+					t.Errorf("row %d = %q, want %q", i+1, row, rowOf(tag))
+				}
+				if row[index["name"]] != tag.Name {
+					t.Errorf("row %d name = %q, want %q", i+1, row[index["name"]], tag.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   rune
+		hasErr bool
+	}{
+		{"", ',', false},
+		{",", ',', false},
+		{";", ';', false},
+		{"tab", '\t', false},
+		{`\t`, '\t', false},
+		{"|", '|', false},
+		{"、", '、', false},
+		{`"`, 0, true},
+		{"\n", 0, true},
+		{",;", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDelimiter(tt.in)
+		if (err != nil) != tt.hasErr || got != tt.want {
+			t.Errorf("ParseDelimiter(%q) = %q, %v, want %q, error %v", tt.in, got, err, tt.want, tt.hasErr)
+		}
+	}
+}
+
+func TestGetFormat(t *testing.T) {
+	contentTypes := map[string]string{
+		"text/csv; charset=utf-8": FORMAT_CSV,
+		"application/json":        FORMAT_JSON,
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": FORMAT_XLSX,
+		"text/plain": "",
+		"":           "",
+	}
+	for contentType, want := range contentTypes {
+		if got := GetFormatByContentType(contentType); got != want {
+			t.Errorf("GetFormatByContentType(%q) = %q, want %q", contentType, got, want)
+		}
+	}
+
+	names := map[string]string{
+		"tags.xlsx": FORMAT_XLSX,
+		"TAGS.CSV":  FORMAT_CSV,
+		"tags.tsv":  FORMAT_CSV,
+		"tags.json": FORMAT_JSON,
+		"tags.txt":  "",
+		"tags":      "",
+	}
+	for name, want := range names {
+		if got := GetFormatByFileName(name); got != want {
+			t.Errorf("GetFormatByFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package tag_service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/export"
+	"github.com/EDDYCJY/go-gin-example/service/sitemap_service"
+)
+
+var ErrNoNameColumn = errors.New("tag sheet has no name column")
+
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// ImportReport tells what an import did, or in a dry run or with failed rows,
+// what it would have done. A file with any failed row is not imported at all
+type ImportReport struct {
+	DryRun    bool             `json:"dry_run"`
+	Committed bool             `json:"committed"`
+	Total     int              `json:"total"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Failed    int              `json:"failed"`
+	Errors    []ImportRowError `json:"errors"`
+
+	// ReportFile is the annotated copy of the file written when rows failed
+	ReportFile string `json:"-"`
+}
+
+func (r *ImportReport) fail(row int, format string, args ...interface{}) {
+	r.Failed++
+	r.Errors = append(r.Errors, ImportRowError{Row: row, Message: fmt.Sprintf(format, args...)})
+}
+
+// Import creates or updates the tags of a table. Rows with an ID update that tag,
+// other rows update the tag of the same name or create one. Every row is checked
+// before anything is written and the whole file is saved in one transaction
+func (t *Tag) Import(r io.Reader, format *Format, dryRun bool) (*ImportReport, error) {
+	rows, err := format.decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.importRows(rows, dryRun)
+}
+
+func (t *Tag) importRows(rows [][]string, dryRun bool) (*ImportReport, error) {
+	if len(rows) == 0 {
+		return nil, ErrNoNameColumn
+	}
+
+	index := mapColumns(rows[0])
+	if _, ok := index["name"]; !ok {
+		return nil, ErrNoNameColumn
+	}
+
+	var (
+		report = &ImportReport{DryRun: dryRun}
+		tags   []*models.Tag
+		names  = make(map[string]int)
+		ids    = make(map[int]int)
+		failed = make(map[int]string)
+	)
+	for i, row := range rows[1:] {
+		n := i + 2
+		if isBlank(row) {
+			continue
+		}
+
+		report.Total++
+		tag, err := t.parseRow(row, index)
+		if err == nil {
+			err = resolveTag(tag)
+		}
+		// after resolveTag, as rows by name target the tag of that name
+		if err == nil {
+			err = checkDuplicate(tag, n, names, ids)
+		}
+		if err != nil {
+			report.fail(n, "%v", err)
+			failed[n] = err.Error()
+			continue
+		}
+
+		if tag.ID > 0 {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+		tags = append(tags, tag)
+	}
+
+	if report.Failed > 0 {
+		filename, err := writeErrorReport(rows, failed)
+		if err != nil {
+			return nil, err
+		}
+		report.ReportFile = filename
+		return report, nil
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	if err := models.SaveTags(tags); err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		sitemap_service.MarkTag(tag.ID)
+	}
+
+	report.Committed = true
+	return report, nil
+}
+
+// parseRow validates a row into a tag. State is -1 when the row leaves it blank
+func (t *Tag) parseRow(row []string, index map[string]int) (*models.Tag, error) {
+	value := func(key string) string {
+		if i, ok := index[key]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	tag := &models.Tag{
+		Name:       value("name"),
+		CreatedBy:  value("created_by"),
+		ModifiedBy: t.ModifiedBy,
+		State:      -1,
+	}
+
+	if v := value("id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("ID必须为正整数")
+		}
+		tag.ID = id
+	}
+	if v := value("state"); v != "" {
+		state, err := strconv.Atoi(v)
+		if err != nil || (state != 0 && state != 1) {
+			return nil, fmt.Errorf("状态只允许0或1")
+		}
+		tag.State = state
+	}
+
+	if tag.Name == "" {
+		return nil, fmt.Errorf("名称不能为空")
+	}
+	if len([]rune(tag.Name)) > 100 {
+		return nil, fmt.Errorf("名称最长为100字符")
+	}
+	if tag.CreatedBy == "" {
+		tag.CreatedBy = t.CreatedBy
+	}
+	if tag.ModifiedBy == "" {
+		tag.ModifiedBy = tag.CreatedBy
+	}
+
+	return tag, nil
+}
+
+// checkDuplicate rejects a row naming a tag or targeting an ID already used by an
+// earlier row. Names are compared regardless of case, as the database collation does
+func checkDuplicate(tag *models.Tag, n int, names map[string]int, ids map[int]int) error {
+	name := strings.ToLower(tag.Name)
+	if prev, ok := names[name]; ok {
+		return fmt.Errorf("名称与第%d行重复", prev)
+	}
+	if prev, ok := ids[tag.ID]; ok && tag.ID > 0 {
+		return fmt.Errorf("ID与第%d行重复", prev)
+	}
+
+	names[name] = n
+	if tag.ID > 0 {
+		ids[tag.ID] = n
+	}
+	return nil
+}
+
+// resolveTag decides whether the row updates an existing tag, setting its ID, or creates one
+func resolveTag(tag *models.Tag) error {
+	existing, err := models.GetTagByName(tag.Name)
+	if err != nil {
+		return err
+	}
+
+	if tag.ID > 0 {
+		if exists, err := models.ExistTagByID(tag.ID); err != nil {
+			return err
+		} else if !exists {
+			return fmt.Errorf("ID为%d的标签不存在", tag.ID)
+		}
+		if existing != nil && existing.ID != tag.ID {
+			return fmt.Errorf("名称已被ID为%d的标签使用", existing.ID)
+		}
+		return nil
+	}
+
+	if existing != nil {
+		tag.ID = existing.ID
+		return nil
+	}
+
+	if tag.CreatedBy == "" {
+		return fmt.Errorf("新建标签的创建人不能为空")
+	}
+	if tag.State < 0 {
+		tag.State = 1
+	}
+	return nil
+}
+
+// writeErrorReport saves a copy of the imported rows with the error of each failed
+// row in an extra column, and returns its file name in the export directory
+func writeErrorReport(rows [][]string, failed map[int]string) (string, error) {
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+
+	xlsx := newSheet(rows)
+	style, err := xlsx.NewStyle(`{"font":{"color":"#FF0000"}}`)
+	if err != nil {
+		return "", err
+	}
+
+	xlsx.SetCellStr(SHEET_NAME, cellName(width, 0), "错误")
+	for n, message := range failed {
+		cell := cellName(width, n-1)
+		xlsx.SetCellStr(SHEET_NAME, cell, message)
+		xlsx.SetCellStyle(SHEET_NAME, cell, cell, style)
+	}
+
+	time := strconv.Itoa(int(time.Now().Unix()))
+	filename := "tags-import-errors-" + time + export.EXT
+
+	err = export.Save(filename, xlsx.Write)
+	if err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// mapColumns finds the position of each known column from the header row
+func mapColumns(header []string) map[string]int {
+	index := make(map[string]int)
+	for i, title := range header {
+		title = strings.TrimSpace(title)
+		for _, c := range tagColumns {
+			if title == c.Title || strings.EqualFold(title, c.Key) {
+				if _, ok := index[c.Key]; !ok {
+					index[c.Key] = i
+				}
+			}
+		}
+	}
+
+	return index
+}
+
+func isBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+
+	return true
+}
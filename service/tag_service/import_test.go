@@ -0,0 +1,59 @@
+package tag_service
+
+import (
+	"testing"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+)
+
+func TestCheckDuplicate(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   []*models.Tag
+		failed []bool
+	}{
+		{
+			name:   "distinct names",
+			tags:   []*models.Tag{{Name: "Go"}, {Name: "Gin"}},
+			failed: []bool{false, false},
+		},
+		{
+			name:   "same name",
+			tags:   []*models.Tag{{Name: "Go"}, {Name: "Go"}},
+			failed: []bool{false, true},
+		},
+		{
+			name:   "names differing in case",
+			tags:   []*models.Tag{{Name: "Go"}, {Name: "go"}, {Name: "GO"}},
+			failed: []bool{false, true, true},
+		},
+		{
+			name:   "same ID",
+			tags:   []*models.Tag{{Model: models.Model{ID: 3}, Name: "Go"}, {Model: models.Model{ID: 3}, Name: "Gin"}},
+			failed: []bool{false, true},
+		},
+		{
+			name:   "row by name resolved to a tag renamed by an earlier row",
+			tags:   []*models.Tag{{Model: models.Model{ID: 5}, Name: "B"}, {Model: models.Model{ID: 5}, Name: "A"}},
+			failed: []bool{false, true},
+		},
+		{
+			name:   "new tags have no ID to clash",
+			tags:   []*models.Tag{{Name: "Go"}, {Name: "Gin"}, {Name: "Gorm"}},
+			failed: []bool{false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := make(map[string]int)
+			ids := make(map[int]int)
+			for i, tag := range tt.tags {
+				err := checkDuplicate(tag, i+2, names, ids)
+				if (err != nil) != tt.failed[i] {
+					t.Errorf("row %d: checkDuplicate() err = %v, want failure %v", i+2, err, tt.failed[i])
+				}
+			}
+		})
+	}
+}
@@ -3,18 +3,13 @@ package tag_service
 import (
 	"encoding/json"
 	"io"
-	"strconv"
-	"time"
-
-	"github.com/360EntSecGroup-Skylar/excelize"
-	"github.com/tealeg/xlsx"
 
 	"github.com/EDDYCJY/go-gin-example/models"
 	"github.com/EDDYCJY/go-gin-example/pkg/export"
-	"github.com/EDDYCJY/go-gin-example/pkg/file"
 	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
 	"github.com/EDDYCJY/go-gin-example/pkg/logging"
 	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+	"github.com/EDDYCJY/go-gin-example/service/sitemap_service"
 )
 
 type Tag struct {
@@ -36,8 +31,23 @@ func (t *Tag) ExistByID() (bool, error) {
 	return models.ExistTagByID(t.ID)
 }
 
+func (t *Tag) Get() (*models.Tag, error) {
+	return models.GetTag(t.ID)
+}
+
+func (t *Tag) GetByName() (*models.Tag, error) {
+	return models.GetTagByName(t.Name)
+}
+
 func (t *Tag) Add() error {
-	return models.AddTag(t.Name, t.State, t.CreatedBy)
+	id, err := models.AddTag(t.Name, t.State, t.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	t.ID = id
+	sitemap_service.MarkTag(id)
+	return nil
 }
 
 func (t *Tag) Edit() error {
@@ -48,11 +58,21 @@ func (t *Tag) Edit() error {
 		data["state"] = t.State
 	}
 
-	return models.EditTag(t.ID, data)
+	if err := models.EditTag(t.ID, data); err != nil {
+		return err
+	}
+
+	sitemap_service.MarkTag(t.ID)
+	return nil
 }
 
 func (t *Tag) Delete() error {
-	return models.DeleteTag(t.ID)
+	if err := models.DeleteTag(t.ID); err != nil {
+		return err
+	}
+
+	sitemap_service.MarkTag(t.ID)
+	return nil
 }
 
 func (t *Tag) Count() (int, error) {
@@ -90,80 +110,65 @@ func (t *Tag) GetAll() ([]models.Tag, error) {
 	return tags, nil
 }
 
-func (t *Tag) Export() (string, error) {
-	tags, err := t.GetAll()
-	if err != nil {
-		return "", err
-	}
+// Export stores the matching tags among the export files and returns the file name,
+// which carries the id of the job. progress may be nil
+func (t *Tag) Export(format *Format, id string, progress export.ProgressFunc) (string, error) {
+	filename := export.GetFileName("tags", id, format.Ext())
 
-	xlsFile := xlsx.NewFile()
-	sheet, err := xlsFile.AddSheet("标签信息")
+	err := export.Save(filename, func(w io.Writer) error {
+		return t.Write(w, format, progress)
+	})
 	if err != nil {
 		return "", err
 	}
 
-	titles := []string{"ID", "名称", "创建人", "创建时间", "修改人", "修改时间"}
-	row := sheet.AddRow()
-
-	var cell *xlsx.Cell
-	for _, title := range titles {
-		cell = row.AddCell()
-		cell.Value = title
-	}
-
-	for _, v := range tags {
-		values := []string{
-			strconv.Itoa(v.ID),
-			v.Name,
-			v.CreatedBy,
-			strconv.Itoa(v.CreatedOn),
-			v.ModifiedBy,
-			strconv.Itoa(v.ModifiedOn),
-		}
+	return filename, nil
+}
 
-		row = sheet.AddRow()
-		for _, value := range values {
-			cell = row.AddCell()
-			cell.Value = value
-		}
+// Write streams the matching tags to w, reading them from the database a batch
+// at a time so that memory does not grow with the table. progress may be nil
+func (t *Tag) Write(w io.Writer, format *Format, progress export.ProgressFunc) error {
+	if progress == nil {
+		progress = func(done, total int) {}
 	}
 
-	time := strconv.Itoa(int(time.Now().Unix()))
-	filename := "tags-" + time + export.EXT
-
-	dirFullPath := export.GetExcelFullPath()
-	err = file.IsNotExistMkDir(dirFullPath)
+	total, err := t.Count()
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	err = xlsFile.Save(dirFullPath + filename)
+	tw, err := format.newWriter(w)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return filename, nil
-}
+	batchSize := export.GetBatchSize()
+	done, lastID := 0, 0
+	for {
+		tags, err := models.GetTagsAfter(lastID, batchSize, t.getMaps())
+		if err != nil {
+			return err
+		}
 
-func (t *Tag) Import(r io.Reader) error {
-	xlsx, err := excelize.OpenReader(r)
-	if err != nil {
-		return err
-	}
+		for i := range tags {
+			if err := tw.Write(&tags[i]); err != nil {
+				return err
+			}
 
-	rows := xlsx.GetRows("标签信息")
-	for irow, row := range rows {
-		if irow > 0 {
-			var data []string
-			for _, cell := range row {
-				data = append(data, cell)
+			done++
+			if done > total {
+				total = done
 			}
+			progress(done, total)
+		}
 
-			models.AddTag(data[1], 1, data[2])
+		if len(tags) < batchSize {
+			break
 		}
+		lastID = tags[len(tags)-1].ID
 	}
 
-	return nil
+	return tw.Close()
 }
 
 func (t *Tag) getMaps() map[string]interface{} {
@@ -0,0 +1,489 @@
+package tus_service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/file"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/service/attachment_service"
+	"github.com/EDDYCJY/go-gin-example/service/media_service"
+	"github.com/EDDYCJY/go-gin-example/service/quota_service"
+)
+
+var (
+	ErrNotFound       = errors.New("upload not found")
+	ErrOffsetMismatch = errors.New("upload offset does not match")
+	ErrLocked         = errors.New("upload is being written")
+	ErrFinished       = errors.New("upload is finished")
+	ErrNoArticle      = errors.New("attachment upload names no article")
+)
+
+// Upload is a resumable upload. Its data is appended to a file in the save
+// path and its state kept beside it, so that an upload interrupted by a lost
+// connection or a restart carries on from the bytes already written
+type Upload struct {
+	ID        string            `json:"id"`
+	Length    int64             `json:"length"`
+	Offset    int64             `json:"offset"`
+	Metadata  map[string]string `json:"metadata"`
+	Owner     string            `json:"owner"`
+	CreatedBy string            `json:"created_by"`
+	CreatedOn int64             `json:"created_on"`
+	ExpiresOn int64             `json:"expires_on"`
+
+	// MediaID is the media a finished image was stored as
+	MediaID int `json:"media_id,omitempty"`
+	// AttachmentID is the attachment any other finished file was stored as
+	AttachmentID int `json:"attachment_id,omitempty"`
+}
+
+// The files of an upload in the save path: its data, its state and the state
+// being written
+const (
+	dataExt = ".bin"
+	infoExt = ".info"
+	tmpExt  = ".tmp"
+)
+
+var (
+	mu     sync.Mutex
+	locked = make(map[string]bool)
+)
+
+// GetSavePath get the directory uploads in progress are kept in, on local disk
+// whatever the storage of finished files
+func GetSavePath() string {
+	return setting.AppSetting.RuntimeRootPath + setting.TusSetting.SavePath
+}
+
+// GetMaxSize get the largest upload accepted, of any type. Each type of file
+// has its own limit within it
+func GetMaxSize() int64 {
+	return int64(setting.TusSetting.MaxSize)
+}
+
+// GetFileName get the name of the uploaded file, as tus clients send it
+func (u *Upload) GetFileName() string {
+	if name := u.Metadata["filename"]; name != "" {
+		return name
+	}
+
+	return u.Metadata["name"]
+}
+
+// GetArticleID get the article an attachment is uploaded to, 0 when there is none
+func (u *Upload) GetArticleID() int {
+	id, err := strconv.Atoi(u.Metadata["article_id"])
+	if err != nil || id < 0 {
+		return 0
+	}
+
+	return id
+}
+
+// GetPolicy get the policy the uploaded file is checked against: the image one
+// for images, which go to the media library, or that of the attachment type
+// accepting its extension. It is nil when no type does
+func (u *Upload) GetPolicy() *upload.Policy {
+	ext := path.Ext(u.GetFileName())
+	if upload.CheckImageExt(ext) {
+		return upload.GetImagePolicy()
+	}
+
+	return upload.GetAttachmentPolicy(ext)
+}
+
+// IsImage reports whether the upload goes to the media library rather than
+// being attached to an article
+func (u *Upload) IsImage() bool {
+	policy := u.GetPolicy()
+	return policy != nil && policy.Type == upload.POLICY_IMAGE
+}
+
+// IsFinished reports whether the upload was stored
+func (u *Upload) IsFinished() bool {
+	return u.MediaID > 0 || u.AttachmentID > 0
+}
+
+// Create starts an upload of length bytes for the user. The file name and
+// the length are checked up front against the policy of the file type, so that
+// a client does not send a file that would be refused. Files other than images
+// are attached to the article in the article_id metadata
+func Create(length int64, metadata map[string]string, user *quota_service.User) (*Upload, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	u := &Upload{
+		ID:        id,
+		Length:    length,
+		Metadata:  metadata,
+		Owner:     user.Owner,
+		CreatedBy: user.Name,
+		CreatedOn: now.Unix(),
+		ExpiresOn: now.Add(setting.TusSetting.Expire).Unix(),
+	}
+	policy := u.GetPolicy()
+	switch {
+	case policy == nil:
+		return nil, upload.ErrFileType
+	case policy.Type == upload.POLICY_IMAGE && length > int64(policy.MaxSize):
+		return nil, upload.ErrImageTooLarge
+	case length > int64(policy.MaxSize) || length > GetMaxSize():
+		return nil, upload.ErrFileTooLarge
+	case policy.Type != upload.POLICY_IMAGE && u.GetArticleID() == 0:
+		return nil, ErrNoArticle
+	}
+
+	if err := file.IsNotExistMkDir(GetSavePath()); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(u.getDataPath(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	if err := u.save(); err != nil {
+		os.Remove(u.getDataPath())
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Get returns an upload, or ErrNotFound if there is no such upload or it has expired
+func Get(id string) (*Upload, error) {
+	if !checkID(id) {
+		return nil, ErrNotFound
+	}
+
+	data, err := ioutil.ReadFile(getInfoPath(id))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var u Upload
+	if err := json.Unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	if time.Now().Unix() > u.ExpiresOn {
+		return nil, ErrNotFound
+	}
+
+	// the data file is the truth about what arrived, as a write may stop anywhere
+	if !u.IsFinished() {
+		info, err := os.Stat(u.getDataPath())
+		if err != nil {
+			return nil, err
+		}
+		u.Offset = info.Size()
+	}
+
+	return &u, nil
+}
+
+// Write appends the body of a PATCH request at offset, which must be where
+// the upload stands. It returns the bytes written, kept even when reading the
+// body fails halfway
+func (u *Upload) Write(offset int64, r io.Reader) (int64, error) {
+	if !lock(u.ID) {
+		return 0, ErrLocked
+	}
+	defer unlock(u.ID)
+
+	if err := u.reload(); err != nil {
+		return 0, err
+	}
+	if u.IsFinished() {
+		return 0, ErrFinished
+	}
+	if offset != u.Offset {
+		return 0, ErrOffsetMismatch
+	}
+
+	f, err := os.OpenFile(u.getDataPath(), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, u.Length-u.Offset))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	u.Offset += n
+	u.ExpiresOn = time.Now().Add(setting.TusSetting.Expire).Unix()
+	if serr := u.save(); err == nil {
+		err = serr
+	}
+
+	return n, err
+}
+
+// Finish checks the whole upload like a direct upload and stores it, in the
+// media library or as an attachment. An upload that the checks refuse is
+// terminated
+func (u *Upload) Finish() error {
+	if !lock(u.ID) {
+		return ErrLocked
+	}
+	defer unlock(u.ID)
+
+	if err := u.reload(); err != nil {
+		return err
+	}
+	if u.IsFinished() {
+		return ErrFinished
+	}
+
+	f, err := os.Open(u.getDataPath())
+	if err != nil {
+		return err
+	}
+	if u.IsImage() {
+		err = u.finishImage(f)
+	} else {
+		err = u.finishAttachment(f)
+	}
+	f.Close()
+	switch err {
+	case nil:
+	case upload.ErrImageTooLarge, upload.ErrImageDimensions, upload.ErrNotImage, upload.ErrImageExt,
+		upload.ErrFileType, upload.ErrFileTooLarge:
+		u.remove()
+		return err
+	default:
+		return err
+	}
+
+	// the state is kept until it expires so that a client resuming after the
+	// last PATCH learns the upload is complete
+	if err := u.save(); err != nil {
+		return err
+	}
+	os.Remove(u.getDataPath())
+
+	return nil
+}
+
+func (u *Upload) finishImage(f *os.File) error {
+	content, err := upload.ReadImage(f, path.Ext(u.GetFileName()))
+	if err != nil {
+		return err
+	}
+
+	mediaService := media_service.Media{
+		Data:         content,
+		OriginalName: u.GetFileName(),
+		AltText:      u.Metadata["alt_text"],
+		Caption:      u.Metadata["caption"],
+		Owner:        u.Owner,
+		CreatedBy:    u.CreatedBy,
+	}
+	media, _, err := mediaService.Save()
+	if err != nil {
+		return err
+	}
+
+	u.MediaID = media.ID
+	return nil
+}
+
+func (u *Upload) finishAttachment(f *os.File) error {
+	attachmentService := attachment_service.Attachment{
+		ArticleID:    u.GetArticleID(),
+		OriginalName: u.GetFileName(),
+		Owner:        u.Owner,
+		CreatedBy:    u.CreatedBy,
+	}
+	attachment, err := attachmentService.Add(f)
+	if err != nil {
+		return err
+	}
+
+	u.AttachmentID = attachment.ID
+	return nil
+}
+
+// Terminate stops an upload and removes what was received
+func (u *Upload) Terminate() error {
+	if !lock(u.ID) {
+		return ErrLocked
+	}
+	defer unlock(u.ID)
+
+	return u.remove()
+}
+
+// GetPendingSize adds up the length of the unfinished uploads of an account,
+// which take room on disk before they count in its usage
+func GetPendingSize(owner string) (int64, error) {
+	names, err := filepath.Glob(GetSavePath() + "*" + infoExt)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, name := range names {
+		u, err := Get(strings.TrimSuffix(filepath.Base(name), infoExt))
+		// uploads expired or removed meanwhile take no room
+		if err != nil {
+			continue
+		}
+		if u.Owner == owner && !u.IsFinished() {
+			size += u.Length
+		}
+	}
+
+	return size, nil
+}
+
+// Clean removes the uploads that expired, as well as data whose state was lost
+// and states left half written by a crash
+func Clean() error {
+	names, err := filepath.Glob(GetSavePath() + "*")
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(-setting.TusSetting.Expire)
+	for _, name := range names {
+		base := filepath.Base(name)
+		i := strings.Index(base, ".")
+		if i < 0 || !checkID(base[:i]) {
+			continue
+		}
+		id, kind := base[:i], base[i:]
+
+		switch kind {
+		case infoExt:
+			if _, err := Get(id); err != ErrNotFound {
+				continue
+			}
+		case dataExt, infoExt + tmpExt:
+			info, err := os.Stat(name)
+			if err != nil || info.ModTime().After(deadline) {
+				continue
+			}
+			if kind == dataExt && !file.CheckNotExist(getInfoPath(id)) {
+				continue
+			}
+		default:
+			continue
+		}
+
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			logging.Warn("tus_service.Clean remove", name, "err:", err)
+		}
+		if kind == infoExt {
+			os.Remove(getDataPath(id))
+		}
+	}
+
+	return nil
+}
+
+// reload reads the state again once locked, as another request may have
+// written the upload since it was read
+func (u *Upload) reload() error {
+	current, err := Get(u.ID)
+	if err != nil {
+		return err
+	}
+
+	*u = *current
+	return nil
+}
+
+func (u *Upload) save() error {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+
+	// written aside and renamed so that a crash never leaves half a state
+	tmp := getInfoPath(u.ID) + tmpExt
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, getInfoPath(u.ID))
+}
+
+func (u *Upload) remove() error {
+	if err := os.Remove(u.getDataPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(getInfoPath(u.ID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (u *Upload) getDataPath() string {
+	return getDataPath(u.ID)
+}
+
+func getDataPath(id string) string {
+	return GetSavePath() + id + dataExt
+}
+
+func getInfoPath(id string) string {
+	return GetSavePath() + id + infoExt
+}
+
+// lock keeps a single request writing an upload at a time
+func lock(id string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if locked[id] {
+		return false
+	}
+	locked[id] = true
+	return true
+}
+
+func unlock(id string) {
+	mu.Lock()
+	delete(locked, id)
+	mu.Unlock()
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// checkID keeps ids from the URL to the names newID makes
+func checkID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
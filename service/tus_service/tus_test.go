@@ -0,0 +1,163 @@
+package tus_service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/service/quota_service"
+)
+
+var user = &quota_service.User{Owner: "owner", Name: "test"}
+
+func setup(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "tus")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setting.AppSetting.RuntimeRootPath = dir + "/"
+	setting.AppSetting.ImageAllowExts = []string{".jpg", ".png"}
+	setting.AppSetting.ImageMaxSize = 5 << 20
+	setting.AttachmentSetting.Types = map[string]*setting.AttachmentType{
+		"document": {MimeTypes: []string{"application/pdf"}, Exts: []string{".pdf"}, MaxSize: 20 << 20},
+	}
+	setting.TusSetting.SavePath = "tus/"
+	setting.TusSetting.MaxSize = 10 << 20
+	setting.TusSetting.Expire = time.Hour
+
+	return func() { os.RemoveAll(dir) }
+}
+
+func TestCreate(t *testing.T) {
+	defer setup(t)()
+
+	tests := []struct {
+		name     string
+		length   int64
+		metadata map[string]string
+		err      error
+		image    bool
+	}{
+		{"image", 1 << 20, map[string]string{"filename": "a.png"}, nil, true},
+		{"image over its limit", 6 << 20, map[string]string{"filename": "a.png"}, upload.ErrImageTooLarge, true},
+		{"attachment", 8 << 20, map[string]string{"filename": "a.pdf", "article_id": "3"}, nil, false},
+		{"attachment over the tus limit", 11 << 20, map[string]string{"filename": "a.pdf", "article_id": "3"}, upload.ErrFileTooLarge, false},
+		{"attachment without article", 1 << 20, map[string]string{"filename": "a.pdf"}, ErrNoArticle, false},
+		{"attachment with invalid article", 1 << 20, map[string]string{"filename": "a.pdf", "article_id": "x"}, ErrNoArticle, false},
+		{"unknown type", 1 << 20, map[string]string{"filename": "a.exe"}, upload.ErrFileType, false},
+		{"no file name", 1 << 20, map[string]string{}, upload.ErrFileType, false},
+	}
+
+	for _, tt := range tests {
+		u, err := Create(tt.length, tt.metadata, user)
+		if err != tt.err {
+			t.Errorf("%s: Create() err = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if u.IsImage() != tt.image {
+			t.Errorf("%s: IsImage() = %v, want %v", tt.name, u.IsImage(), tt.image)
+		}
+		if got, err := Get(u.ID); err != nil || got.Offset != 0 || got.Length != tt.length {
+			t.Errorf("%s: Get() = %+v, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestGetPendingSize(t *testing.T) {
+	defer setup(t)()
+
+	other := &quota_service.User{Owner: "other", Name: "other"}
+	uploads := []struct {
+		length int64
+		user   *quota_service.User
+	}{
+		{10, user},
+		{20, user},
+		{40, other},
+	}
+	for _, tt := range uploads {
+		if _, err := Create(tt.length, map[string]string{"filename": "a.png"}, tt.user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		owner string
+		want  int64
+	}{
+		{user.Owner, 30},
+		{other.Owner, 40},
+		{"nobody", 0},
+	}
+
+	for _, tt := range tests {
+		if got, err := GetPendingSize(tt.owner); err != nil || got != tt.want {
+			t.Errorf("GetPendingSize(%q) = %d, %v, want %d", tt.owner, got, err, tt.want)
+		}
+	}
+}
+
+func TestClean(t *testing.T) {
+	defer setup(t)()
+
+	live, err := Create(10, map[string]string{"filename": "a.png"}, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := Create(10, map[string]string{"filename": "b.png"}, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired.ExpiresOn = time.Now().Add(-time.Minute).Unix()
+	if err := expired.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	const orphan, staleTmp, freshTmp = "0123456789abcdef0123456789abcdef", "1123456789abcdef0123456789abcdef", "2123456789abcdef0123456789abcdef"
+	old := time.Now().Add(-2 * time.Hour)
+	for name, modTime := range map[string]time.Time{
+		getDataPath(orphan):            old,
+		getInfoPath(staleTmp) + tmpExt: old,
+		getInfoPath(freshTmp) + tmpExt: time.Now(),
+		GetSavePath() + "other.txt":    old,
+	} {
+		if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Clean(); err != nil {
+		t.Fatalf("Clean() err = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		kept bool
+	}{
+		{getDataPath(live.ID), true},
+		{getInfoPath(live.ID), true},
+		{getDataPath(expired.ID), false},
+		{getInfoPath(expired.ID), false},
+		{getDataPath(orphan), false},
+		{getInfoPath(staleTmp) + tmpExt, false},
+		{getInfoPath(freshTmp) + tmpExt, true},
+		{GetSavePath() + "other.txt", true},
+	}
+	for _, tt := range tests {
+		_, err := os.Stat(tt.name)
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("%s kept = %v, want %v", filepath.Base(tt.name), kept, tt.kept)
+		}
+	}
+}
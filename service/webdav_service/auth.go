@@ -0,0 +1,57 @@
+package webdav_service
+
+import (
+	"time"
+
+	"github.com/EDDYCJY/go-gin-example/pkg/gredis"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/service/cache_service"
+)
+
+// AllowSignIn reports whether a client address may try to sign in, or else
+// how long until it may again. Clients are let through when Redis cannot
+// count their failures
+func AllowSignIn(ip string) (bool, time.Duration) {
+	limit := setting.WebdavSetting.MaxAuthFailures
+	window := setting.WebdavSetting.AuthFailureWindow
+	if limit <= 0 || window <= 0 {
+		return true, 0
+	}
+
+	now := time.Now()
+	failures, err := countFailures(ip, now, window, 0)
+	if err != nil {
+		logging.Warn("webdav_service.AllowSignIn", ip, "err:", err)
+		return true, 0
+	}
+
+	return checkFailures(failures, limit, now, window)
+}
+
+// FailSignIn counts a failed sign in of a client address
+func FailSignIn(ip string) {
+	window := setting.WebdavSetting.AuthFailureWindow
+	if setting.WebdavSetting.MaxAuthFailures <= 0 || window <= 0 {
+		return
+	}
+
+	if _, err := countFailures(ip, time.Now(), window, 1); err != nil {
+		logging.Warn("webdav_service.FailSignIn", ip, "err:", err)
+	}
+}
+
+// countFailures adds to the failures of the window now falls in and returns them
+func countFailures(ip string, now time.Time, window time.Duration, incr int) (int, error) {
+	cache := cache_service.WebDAVAuthFailure{IP: ip, Window: now.Truncate(window).Unix()}
+	return gredis.IncrBy(cache.GetWebDAVAuthFailureKey(), incr, int(window/time.Second)+1)
+}
+
+// checkFailures decides on a sign in after failures of the window now falls in
+func checkFailures(failures, limit int, now time.Time, window time.Duration) (bool, time.Duration) {
+	if failures >= limit {
+		return false, now.Truncate(window).Add(window).Sub(now)
+	}
+
+	return true, 0
+}
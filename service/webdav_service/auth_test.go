@@ -0,0 +1,28 @@
+package webdav_service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckFailures(t *testing.T) {
+	now := time.Date(2019, 5, 1, 10, 10, 0, 0, time.UTC)
+
+	tests := []struct {
+		failures int
+		ok       bool
+		wait     time.Duration
+	}{
+		{0, true, 0},
+		{9, true, 0},
+		{10, false, 5 * time.Minute},
+		{11, false, 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		ok, wait := checkFailures(tt.failures, 10, now, 15*time.Minute)
+		if ok != tt.ok || wait != tt.wait {
+			t.Errorf("checkFailures(%d) = %v, %v, want %v, %v", tt.failures, ok, wait, tt.ok, tt.wait)
+		}
+	}
+}
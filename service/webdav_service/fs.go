@@ -0,0 +1,538 @@
+package webdav_service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/EDDYCJY/go-gin-example/models"
+	"github.com/EDDYCJY/go-gin-example/pkg/logging"
+	"github.com/EDDYCJY/go-gin-example/pkg/setting"
+	"github.com/EDDYCJY/go-gin-example/pkg/storage"
+	"github.com/EDDYCJY/go-gin-example/pkg/upload"
+	"github.com/EDDYCJY/go-gin-example/service/media_service"
+	"github.com/EDDYCJY/go-gin-example/service/quota_service"
+)
+
+// placeholderTTL is how long an empty file a client created stays listed
+// without content being written to it
+const placeholderTTL = 5 * time.Minute
+
+// numberedRegexp matches the names older media are listed under when a newer
+// one has the same file name, such as "cat (12).jpg"
+var numberedRegexp = regexp.MustCompile(`^(.*) \(([0-9]+)\)(\.[^.]*)?$`)
+
+// FS is the media library as a WebDAV file system: one directory with every
+// live image under the file name it was uploaded with. Files written to it
+// are checked and registered like uploads through UploadImage, so writing
+// over a file adds a new media and leaves the old one, which articles may
+// still use, listed under a numbered name. Content already in the library
+// is not stored twice and keeps the name it has.
+//
+// File managers create an empty file before writing the content and leave
+// dot files such as .DS_Store around. Empty files are kept in memory for a
+// while and dot files are thrown away
+type FS struct {
+	mu           sync.Mutex
+	placeholders map[string]time.Time
+}
+
+type contextKey struct{}
+
+func NewFS() *FS {
+	return &FS{placeholders: make(map[string]time.Time)}
+}
+
+// WithUser returns a context carrying the account writing to the file system
+func WithUser(ctx context.Context, user *quota_service.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+func getUser(ctx context.Context) *quota_service.User {
+	if user, ok := ctx.Value(contextKey{}).(*quota_service.User); ok {
+		return user
+	}
+
+	return &quota_service.User{}
+}
+
+// IsDotFile reports whether a name is one of the dot files file managers write
+func IsDotFile(name string) bool {
+	return strings.HasPrefix(path.Base(name), ".")
+}
+
+func (fs *FS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return os.ErrPermission
+}
+
+func (fs *FS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	name, isRoot, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	writing := flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0
+	if isRoot {
+		if writing {
+			return nil, os.ErrPermission
+		}
+		return &dirFile{fs: fs}, nil
+	}
+
+	if writing {
+		if !IsDotFile(name) && !upload.CheckImageExt(name) {
+			return nil, os.ErrPermission
+		}
+		return &writeFile{fs: fs, ctx: ctx, name: name}, nil
+	}
+
+	media, err := resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if media == nil {
+		if fs.hasPlaceholder(name) {
+			return &readFile{info: newPlaceholderInfo(name), data: []byte{}}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	return &readFile{info: newMediaInfo(name, media), media: media}, nil
+}
+
+func (fs *FS) RemoveAll(ctx context.Context, name string) error {
+	name, isRoot, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	if isRoot {
+		return os.ErrPermission
+	}
+	if IsDotFile(name) {
+		return nil
+	}
+	if fs.removePlaceholder(name) {
+		return nil
+	}
+
+	media, err := resolve(name)
+	if err != nil {
+		return err
+	}
+	if media == nil {
+		return os.ErrNotExist
+	}
+	if !getUser(ctx).CanChange(media.Owner) {
+		return os.ErrPermission
+	}
+
+	// as through the API, media articles use are kept
+	mediaService := media_service.Media{ID: media.ID}
+	if err := mediaService.Delete(); err != media_service.ErrMediaInUse {
+		return err
+	}
+
+	return os.ErrPermission
+}
+
+// Rename changes the file name of a media. The extension has to stay, as it
+// agrees with the content
+func (fs *FS) Rename(ctx context.Context, oldName, newName string) error {
+	oldName, oldRoot, err := cleanName(oldName)
+	if err != nil {
+		return err
+	}
+	newName, newRoot, err := cleanName(newName)
+	if err != nil {
+		return err
+	}
+	if oldRoot || newRoot || IsDotFile(newName) || !strings.EqualFold(path.Ext(oldName), path.Ext(newName)) {
+		return os.ErrPermission
+	}
+
+	if fs.removePlaceholder(oldName) {
+		fs.addPlaceholder(newName)
+		return nil
+	}
+
+	media, err := resolve(oldName)
+	if err != nil {
+		return err
+	}
+	if media == nil {
+		return os.ErrNotExist
+	}
+	if !getUser(ctx).CanChange(media.Owner) {
+		return os.ErrPermission
+	}
+
+	mediaService := media_service.Media{ID: media.ID, OriginalName: newName}
+	return mediaService.Rename()
+}
+
+func (fs *FS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	name, isRoot, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+	if isRoot {
+		return &fileInfo{name: "/", dir: true, modTime: time.Now()}, nil
+	}
+
+	media, err := resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if media == nil {
+		if fs.hasPlaceholder(name) {
+			return newPlaceholderInfo(name), nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	return newMediaInfo(name, media), nil
+}
+
+// list returns the entries of the directory, media first and placeholders after
+func (fs *FS) list() ([]os.FileInfo, error) {
+	media, err := models.GetAllMedia(map[string]interface{}{"deleted_on": 0})
+	if err != nil {
+		return nil, err
+	}
+
+	// the newest media of a name gets the name, the older ones a numbered one
+	newest := make(map[string]int)
+	for _, m := range media {
+		newest[getOriginalName(m)] = m.ID
+	}
+
+	infos := make([]os.FileInfo, 0, len(media))
+	listed := make(map[string]bool, len(media))
+	for _, m := range media {
+		name := getOriginalName(m)
+		if newest[name] != m.ID || name == "" {
+			name = getNumberedName(m)
+		}
+		infos = append(infos, newMediaInfo(name, m))
+		listed[name] = true
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for name, expires := range fs.placeholders {
+		if time.Now().After(expires) {
+			delete(fs.placeholders, name)
+			continue
+		}
+		if !listed[name] {
+			infos = append(infos, newPlaceholderInfo(name))
+		}
+	}
+
+	return infos, nil
+}
+
+func (fs *FS) addPlaceholder(name string) {
+	fs.mu.Lock()
+	fs.placeholders[name] = time.Now().Add(placeholderTTL)
+	fs.mu.Unlock()
+}
+
+func (fs *FS) hasPlaceholder(name string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	expires, ok := fs.placeholders[name]
+	return ok && time.Now().Before(expires)
+}
+
+func (fs *FS) removePlaceholder(name string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	_, ok := fs.placeholders[name]
+	delete(fs.placeholders, name)
+	return ok
+}
+
+// save checks a written file like UploadImage does and registers it as a media
+func (fs *FS) save(ctx context.Context, name string, data []byte) error {
+	content, err := upload.CleanImage(data, path.Ext(name))
+	if err != nil {
+		return err
+	}
+
+	user := getUser(ctx)
+	reservation, err := user.Reserve(int64(len(content)))
+	if err != nil {
+		return err
+	}
+	defer reservation.Release()
+
+	mediaService := media_service.Media{
+		Data:         content,
+		OriginalName: name,
+		Owner:        user.Owner,
+		CreatedBy:    user.Name,
+	}
+	media, _, err := mediaService.Save()
+	if err != nil {
+		return err
+	}
+
+	if _, err := upload.MakeVariants(media.Name); err != nil {
+		logging.Warn(err)
+	}
+	return nil
+}
+
+// cleanName turns a path of the file system into a file name, as there are no
+// directories below the root
+func cleanName(name string) (string, bool, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "", true, nil
+	}
+	if strings.Contains(name, "/") {
+		return "", false, os.ErrNotExist
+	}
+
+	return name, false, nil
+}
+
+// resolve finds the media listed under a name, or nil
+func resolve(name string) (*models.Media, error) {
+	media, err := models.GetMediaByOriginalName(name)
+	if err != nil || media != nil {
+		return media, err
+	}
+
+	match := numberedRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(match[2])
+	if err != nil {
+		return nil, nil
+	}
+	media, err = models.GetMedia(id)
+	if err != nil {
+		return nil, err
+	}
+	if media.ID == 0 || getNumberedName(media) != name {
+		return nil, nil
+	}
+
+	return media, nil
+}
+
+// getOriginalName get the file name of a media, which may be empty for media
+// that came from imports
+func getOriginalName(media *models.Media) string {
+	return strings.Replace(path.Base("/"+media.OriginalName), "/", "", -1)
+}
+
+func getNumberedName(media *models.Media) string {
+	name := getOriginalName(media)
+	if name == "" {
+		name = "media" + path.Ext(media.Name)
+	}
+	ext := path.Ext(name)
+
+	return strings.TrimSuffix(name, ext) + " (" + strconv.Itoa(media.ID) + ")" + ext
+}
+
+// fileInfo describes an entry, with the ETag and content type webdav asks for
+// when listing so that it does not open every file
+type fileInfo struct {
+	name        string
+	size        int64
+	modTime     time.Time
+	dir         bool
+	etag        string
+	contentType string
+}
+
+func newMediaInfo(name string, media *models.Media) *fileInfo {
+	modTime := media.ModifiedOn
+	if modTime == 0 {
+		modTime = media.CreatedOn
+	}
+
+	return &fileInfo{
+		name:        name,
+		size:        int64(media.Size),
+		modTime:     time.Unix(int64(modTime), 0),
+		etag:        `"` + media.Hash + `"`,
+		contentType: media.ContentType,
+	}
+}
+
+func newPlaceholderInfo(name string) *fileInfo {
+	return &fileInfo{name: name, modTime: time.Now()}
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.dir }
+func (fi *fileInfo) Sys() interface{}   { return nil }
+
+func (fi *fileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (fi *fileInfo) ETag(ctx context.Context) (string, error) {
+	if fi.etag == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return fi.etag, nil
+}
+
+func (fi *fileInfo) ContentType(ctx context.Context) (string, error) {
+	if fi.contentType == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return fi.contentType, nil
+}
+
+// dirFile is the root directory
+type dirFile struct {
+	fs    *FS
+	infos []os.FileInfo
+	read  bool
+}
+
+func (d *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !d.read {
+		infos, err := d.fs.list()
+		if err != nil {
+			return nil, err
+		}
+		d.infos, d.read = infos, true
+	}
+
+	if count <= 0 {
+		infos := d.infos
+		d.infos = nil
+		return infos, nil
+	}
+	if len(d.infos) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(d.infos) {
+		count = len(d.infos)
+	}
+	infos := d.infos[:count]
+	d.infos = d.infos[count:]
+	return infos, nil
+}
+
+func (d *dirFile) Stat() (os.FileInfo, error) {
+	return &fileInfo{name: "/", dir: true, modTime: time.Now()}, nil
+}
+
+func (d *dirFile) Read(p []byte) (int, error)                   { return 0, os.ErrInvalid }
+func (d *dirFile) Write(p []byte) (int, error)                  { return 0, os.ErrPermission }
+func (d *dirFile) Seek(offset int64, whence int) (int64, error) { return 0, os.ErrInvalid }
+func (d *dirFile) Close() error                                 { return nil }
+
+// readFile is a media opened for reading, loaded on first use as the storage
+// may not seek
+type readFile struct {
+	info   *fileInfo
+	media  *models.Media
+	data   []byte
+	reader *bytes.Reader
+}
+
+func (f *readFile) load() error {
+	if f.reader != nil {
+		return nil
+	}
+	if f.data == nil {
+		r, err := storage.Images.Get(f.media.Name)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		if f.data, err = ioutil.ReadAll(r); err != nil {
+			return err
+		}
+	}
+
+	f.reader = bytes.NewReader(f.data)
+	return nil
+}
+
+func (f *readFile) Read(p []byte) (int, error) {
+	if err := f.load(); err != nil {
+		return 0, err
+	}
+	return f.reader.Read(p)
+}
+
+func (f *readFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.load(); err != nil {
+		return 0, err
+	}
+	return f.reader.Seek(offset, whence)
+}
+
+func (f *readFile) Readdir(count int) ([]os.FileInfo, error) { return nil, os.ErrInvalid }
+func (f *readFile) Stat() (os.FileInfo, error)               { return f.info, nil }
+func (f *readFile) Write(p []byte) (int, error)              { return 0, os.ErrPermission }
+func (f *readFile) Close() error                             { return nil }
+
+// writeFile collects what is written and saves it on Close
+type writeFile struct {
+	fs   *FS
+	ctx  context.Context
+	name string
+	buf  bytes.Buffer
+}
+
+func (f *writeFile) Write(p []byte) (int, error) {
+	if f.buf.Len()+len(p) > setting.AppSetting.ImageMaxSize {
+		return 0, upload.ErrImageTooLarge
+	}
+	return f.buf.Write(p)
+}
+
+func (f *writeFile) Close() error {
+	if IsDotFile(f.name) {
+		return nil
+	}
+	if f.buf.Len() == 0 {
+		f.fs.addPlaceholder(f.name)
+		return nil
+	}
+
+	if err := f.fs.save(f.ctx, f.name, f.buf.Bytes()); err != nil {
+		return err
+	}
+	f.fs.removePlaceholder(f.name)
+	return nil
+}
+
+func (f *writeFile) Stat() (os.FileInfo, error) {
+	return &fileInfo{name: f.name, size: int64(f.buf.Len()), modTime: time.Now()}, nil
+}
+
+func (f *writeFile) Read(p []byte) (int, error)                   { return 0, os.ErrInvalid }
+func (f *writeFile) Seek(offset int64, whence int) (int64, error) { return 0, os.ErrInvalid }
+func (f *writeFile) Readdir(count int) ([]os.FileInfo, error)     { return nil, os.ErrInvalid }